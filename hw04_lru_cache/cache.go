@@ -1,78 +1,80 @@
 package hw04lrucache
 
-import "sync"
+import (
+	"time"
+
+	"github.com/MarinaBiryukova/hw-otus/hw04_lru_cache/lru"
+)
 
 type Key string
 
-type Cache interface {
-	Set(key Key, value interface{}) bool
-	Get(key Key) (interface{}, bool)
-	Clear()
+// Cache is an untyped LRU cache. Use package lru directly for typed keys and values.
+type Cache = lru.Cache[Key, interface{}]
+
+type (
+	Option      = lru.Option[Key, interface{}]
+	EvictFunc   = lru.EvictFunc[Key, interface{}]
+	EvictReason = lru.EvictReason
+	Stats       = lru.Stats
+	Policy      = lru.Policy
+	Sizer       = lru.Sizer[interface{}]
+	Codec       = lru.Codec
+)
+
+var (
+	ErrItemTooLarge = lru.ErrItemTooLarge
+	ErrNegativeCost = lru.ErrNegativeCost
+)
+
+const (
+	EvictReasonCapacity = lru.EvictReasonCapacity
+	EvictReasonExpired  = lru.EvictReasonExpired
+	EvictReasonDeleted  = lru.EvictReasonDeleted
+	EvictReasonCleared  = lru.EvictReasonCleared
+)
+
+const (
+	PolicyLRU = lru.PolicyLRU
+	PolicyLFU = lru.PolicyLFU
+	Policy2Q  = lru.Policy2Q
+	PolicyARC = lru.PolicyARC
+)
+
+func NewCache(capacity int, opts ...Option) Cache {
+	return lru.NewCache(capacity, opts...)
 }
 
-type lruCache struct {
-	mu       sync.Mutex
-	capacity int
-	queue    List
-	items    map[Key]*ListItem
+// NewShardedCache spreads keys over shards independent LRU caches to reduce lock contention.
+func NewShardedCache(capacity, shards int, opts ...Option) Cache {
+	return lru.NewShardedCache(capacity, shards, nil, opts...)
 }
 
-type cacheItem struct {
-	key   Key
-	value interface{}
+func WithDefaultTTL(ttl time.Duration) Option {
+	return lru.WithDefaultTTL[Key, interface{}](ttl)
 }
 
-func NewCache(capacity int) Cache {
-	return &lruCache{
-		capacity: capacity,
-		queue:    NewList(),
-		items:    make(map[Key]*ListItem, capacity),
-	}
+func WithJanitor(interval time.Duration) Option {
+	return lru.WithJanitor[Key, interface{}](interval)
 }
 
-func (c *lruCache) Set(key Key, value interface{}) bool {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	item, ok := c.items[key]
-	if ok {
-		item.Value.(*cacheItem).value = value
-		c.queue.MoveToFront(item)
-		return true
-	}
-
-	item = c.queue.PushFront(&cacheItem{
-		key:   key,
-		value: value,
-	})
-	c.items[key] = item
-
-	if c.queue.Len() > c.capacity {
-		back := c.queue.Back()
-		delete(c.items, back.Value.(*cacheItem).key)
-		c.queue.Remove(back)
-	}
-
-	return false
+func WithOnEvict(fn EvictFunc) Option {
+	return lru.WithOnEvict(fn)
 }
 
-func (c *lruCache) Get(key Key) (interface{}, bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	item, ok := c.items[key]
-	if ok {
-		c.queue.MoveToFront(item)
-		return item.Value.(*cacheItem).value, true
-	}
+func WithPolicy(p Policy) Option {
+	return lru.WithPolicy[Key, interface{}](p)
+}
 
-	return nil, false
+func WithMaxCost(maxCost int64) Option {
+	return lru.WithMaxCost[Key, interface{}](maxCost)
 }
 
-func (c *lruCache) Clear() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func WithSizer(sizer Sizer) Option {
+	return lru.WithSizer[Key](sizer)
+}
 
-	c.queue = NewList()
-	c.items = make(map[Key]*ListItem, c.capacity)
+// WithCodec sets the codec of Snapshot and Restore. Values of custom types must be registered with gob.Register
+// for the default gob codec.
+func WithCodec(codec Codec) Option {
+	return lru.WithCodec[Key, interface{}](codec)
 }
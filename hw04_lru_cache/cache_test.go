@@ -1,10 +1,12 @@
 package hw04lrucache
 
 import (
+	"bytes"
 	"math/rand"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -109,8 +111,127 @@ func TestCache(t *testing.T) {
 	})
 }
 
+func TestCacheOptions(t *testing.T) {
+	t.Run("janitor removes expired items", func(t *testing.T) {
+		c := NewCache(5, WithJanitor(10*time.Millisecond))
+		defer c.Close()
+
+		c.SetWithTTL("aaa", 100, 20*time.Millisecond)
+		c.Set("bbb", 200)
+
+		require.Eventually(t, func() bool {
+			return c.Stats().Len == 1
+		}, time.Second, 10*time.Millisecond)
+
+		val, ok := c.Get("bbb")
+		require.True(t, ok)
+		require.Equal(t, 200, val)
+	})
+
+	t.Run("default ttl", func(t *testing.T) {
+		c := NewCache(5, WithDefaultTTL(20*time.Millisecond))
+
+		c.Set("aaa", 100)
+		c.SetWithTTL("bbb", 200, 0)
+
+		require.Eventually(t, func() bool {
+			_, ok := c.Get("aaa")
+			return !ok
+		}, time.Second, 10*time.Millisecond)
+
+		val, ok := c.Get("bbb")
+		require.True(t, ok)
+		require.Equal(t, 200, val)
+	})
+
+	t.Run("on evict", func(t *testing.T) {
+		var evicted []Key
+		var reasons []EvictReason
+		c := NewCache(2, WithOnEvict(func(key Key, _ interface{}, reason EvictReason) {
+			evicted = append(evicted, key)
+			reasons = append(reasons, reason)
+		}))
+
+		c.Set("key1", 1)
+		c.Set("key2", 2)
+		c.Set("key3", 3)
+		c.Delete("key2")
+		c.Clear()
+
+		require.Equal(t, []Key{"key1", "key2", "key3"}, evicted)
+		require.Equal(t, []EvictReason{EvictReasonCapacity, EvictReasonDeleted, EvictReasonCleared}, reasons)
+	})
+
+	t.Run("max cost", func(t *testing.T) {
+		c := NewCache(0, WithMaxCost(10), WithSizer(func(v interface{}) int64 {
+			return int64(len(v.(string)))
+		}))
+
+		c.Set("key1", "aaaaaa")
+		c.Set("key2", "bbbbb")
+
+		_, ok := c.Get("key1")
+		require.False(t, ok)
+
+		_, err := c.SetWithCost("key3", "c", 100)
+		require.ErrorIs(t, err, ErrItemTooLarge)
+		require.Equal(t, int64(5), c.Stats().Cost)
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		src := NewCache(3)
+		src.Set("key1", 1)
+		src.Set("key2", "two")
+		src.Set("key3", 3.5)
+		src.Get("key1")
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, src.Snapshot(buf))
+
+		dst := NewCache(3)
+		require.NoError(t, dst.Restore(buf))
+
+		// key2 is the least recently used one
+		dst.Set("key4", 4)
+		_, ok := dst.Get("key2")
+		require.False(t, ok)
+
+		val, ok := dst.Get("key3")
+		require.True(t, ok)
+		require.Equal(t, 3.5, val)
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		c := NewCache(3, WithPolicy(PolicyLFU))
+
+		c.Set("key1", 1)
+		c.Set("key2", 2)
+		c.Set("key3", 3)
+		c.Get("key1")
+		c.Get("key2")
+
+		// key3 is used less often than others
+		c.Set("key4", 4)
+
+		_, ok := c.Get("key3")
+		require.False(t, ok)
+
+		_, ok = c.Get("key1")
+		require.True(t, ok)
+	})
+}
+
 func TestCacheMultithreading(*testing.T) {
 	c := NewCache(10)
+	testCacheMultithreading(c)
+}
+
+func TestShardedCacheMultithreading(*testing.T) {
+	c := NewShardedCache(10, 4)
+	testCacheMultithreading(c)
+}
+
+func testCacheMultithreading(c Cache) {
 	wg := &sync.WaitGroup{}
 	wg.Add(2)
 
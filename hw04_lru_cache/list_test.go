@@ -93,6 +93,29 @@ func TestList(t *testing.T) {
 		require.Equal(t, []int{1, 3, 2}, getListElemsFromFront(l))
 		require.Equal(t, []int{2, 3, 1}, getListElemsFromBack(l))
 	})
+
+	t.Run("move to back and insert", func(t *testing.T) {
+		l := NewList()
+
+		l.PushBack(1)
+		l.PushBack(2)
+		l.PushBack(3)
+
+		l.MoveToBack(l.Front())
+		require.Equal(t, []int{2, 3, 1}, getListElemsFromFront(l))
+		require.Equal(t, []int{1, 3, 2}, getListElemsFromBack(l))
+
+		l.InsertBefore(4, l.Back())
+		l.InsertAfter(5, l.Front())
+		require.Equal(t, []int{2, 5, 3, 4, 1}, getListElemsFromFront(l))
+		require.Equal(t, []int{1, 4, 3, 5, 2}, getListElemsFromBack(l))
+
+		elems := make([]int, 0, l.Len())
+		for v := range l.All() {
+			elems = append(elems, v.(int))
+		}
+		require.Equal(t, []int{2, 5, 3, 4, 1}, elems)
+	})
 }
 
 func getListElemsFromFront(l List) []int {
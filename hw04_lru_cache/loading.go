@@ -0,0 +1,22 @@
+package hw04lrucache
+
+import (
+	"time"
+
+	"github.com/MarinaBiryukova/hw-otus/hw04_lru_cache/lru"
+)
+
+type (
+	LoadingCache  = lru.LoadingCache[Key, interface{}]
+	LoadingOption = lru.LoadingOption[Key, interface{}]
+	LoaderFunc    = lru.LoaderFunc[Key, interface{}]
+)
+
+// NewLoadingCache adds GetOrLoad to c: concurrent misses of the same key share one loader call.
+func NewLoadingCache(c Cache, opts ...LoadingOption) *LoadingCache {
+	return lru.NewLoadingCache(c, opts...)
+}
+
+func WithErrorTTL(ttl time.Duration) LoadingOption {
+	return lru.WithErrorTTL[Key, interface{}](ttl)
+}
@@ -0,0 +1,39 @@
+package hw04lrucache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadingCache(t *testing.T) {
+	l := NewLoadingCache(NewCache(10))
+
+	calls := 0
+	loader := func(_ context.Context, key Key) (interface{}, error) {
+		calls++
+		if key == "bad" {
+			return nil, errors.New("bad key")
+		}
+		return string(key) + "!", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		val, err := l.GetOrLoad(context.Background(), "aaa", loader)
+		require.NoError(t, err)
+		require.Equal(t, "aaa!", val)
+	}
+	require.Equal(t, 1, calls)
+
+	val, ok := l.Get("aaa")
+	require.True(t, ok)
+	require.Equal(t, "aaa!", val)
+
+	_, err := l.GetOrLoad(context.Background(), "bad", loader)
+	require.Error(t, err)
+
+	_, ok = l.Get("bad")
+	require.False(t, ok)
+}
@@ -0,0 +1,125 @@
+package lru
+
+// arcPolicy is Adaptive Replacement Cache (Megiddo, Modha, 2003).
+// t1 holds items seen once and t2 items seen at least twice, b1 and b2 are the keys recently evicted from them.
+// A hit in b1 grows the target size p of t1, a hit in b2 shrinks it.
+type arcPolicy[K comparable, V any] struct {
+	capacity int
+	p        int
+	t1       List[*cacheItem[K, V]]
+	t2       List[*cacheItem[K, V]]
+	b1       *ghostList[K]
+	b2       *ghostList[K]
+
+	// ghost hit of the key being admitted
+	inB1, inB2 bool
+}
+
+func newARCPolicy[K comparable, V any](capacity int) *arcPolicy[K, V] {
+	return &arcPolicy[K, V]{
+		capacity: capacity,
+		t1:       NewList[*cacheItem[K, V]](),
+		t2:       NewList[*cacheItem[K, V]](),
+		b1:       newGhostList[K](),
+		b2:       newGhostList[K](),
+	}
+}
+
+// size is the number of items the list limits are derived from.
+// A cache bounded by cost has no fixed item count, so the current one is used.
+func (p *arcPolicy[K, V]) size() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return max(1, p.t1.Len()+p.t2.Len())
+}
+
+func (p *arcPolicy[K, V]) admit(key K) {
+	c := p.size()
+
+	switch {
+	case p.b1.remove(key):
+		p.inB1 = true
+		p.p = min(c, p.p+max(p.b2.Len()/max(1, p.b1.Len()), 1))
+	case p.b2.remove(key):
+		p.inB2 = true
+		p.p = max(0, p.p-max(p.b1.Len()/max(1, p.b2.Len()), 1))
+	}
+}
+
+func (p *arcPolicy[K, V]) add(ci *cacheItem[K, V]) {
+	if p.inB1 || p.inB2 {
+		ci.segment = segmentFrequent
+		ci.node = p.t2.PushFront(ci)
+	} else {
+		ci.segment = segmentRecent
+		ci.node = p.t1.PushFront(ci)
+	}
+
+	p.inB1, p.inB2 = false, false
+	p.trimGhosts()
+}
+
+func (p *arcPolicy[K, V]) touch(ci *cacheItem[K, V]) {
+	if ci.segment == segmentFrequent {
+		p.t2.MoveToFront(ci.node)
+		return
+	}
+
+	p.t1.Remove(ci.node)
+	ci.segment = segmentFrequent
+	ci.node = p.t2.PushFront(ci)
+}
+
+func (p *arcPolicy[K, V]) remove(ci *cacheItem[K, V]) {
+	if ci.segment == segmentFrequent {
+		p.t2.Remove(ci.node)
+	} else {
+		p.t1.Remove(ci.node)
+	}
+}
+
+// evict is the REPLACE routine of ARC.
+func (p *arcPolicy[K, V]) evict() *cacheItem[K, V] {
+	t1 := p.t1.Len()
+	if t1 > 0 && (t1 > p.p || (p.inB2 && t1 == p.p) || p.t2.Len() == 0) {
+		ci := popBack(p.t1)
+		p.b1.push(ci.key)
+		p.trimGhosts()
+		return ci
+	}
+
+	ci := popBack(p.t2)
+	if ci != nil {
+		p.b2.push(ci.key)
+		p.trimGhosts()
+	}
+	return ci
+}
+
+// trimGhosts keeps |t1|+|b1| <= c and |t1|+|t2|+|b1|+|b2| <= 2c.
+func (p *arcPolicy[K, V]) trimGhosts() {
+	c := p.size()
+
+	for p.b1.Len() > 0 && p.t1.Len()+p.b1.Len() > c {
+		p.b1.removeOldest()
+	}
+
+	for p.b2.Len() > 0 && p.t1.Len()+p.t2.Len()+p.b1.Len()+p.b2.Len() > 2*c {
+		p.b2.removeOldest()
+	}
+}
+
+func (p *arcPolicy[K, V]) clear() {
+	p.p = 0
+	p.t1 = NewList[*cacheItem[K, V]]()
+	p.t2 = NewList[*cacheItem[K, V]]()
+	p.b1 = newGhostList[K]()
+	p.b2 = newGhostList[K]()
+	p.inB1, p.inB2 = false, false
+}
+
+func (p *arcPolicy[K, V]) each(fn func(ci *cacheItem[K, V])) {
+	eachItem(p.t2, fn)
+	eachItem(p.t1, fn)
+}
@@ -0,0 +1,497 @@
+package lru
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	ErrItemTooLarge = errors.New("item cost exceeds cache max cost")
+	ErrNegativeCost = errors.New("item cost is negative")
+)
+
+type Cache[K comparable, V any] interface {
+	Set(key K, value V) bool
+	SetWithTTL(key K, value V, ttl time.Duration) bool
+	SetWithCost(key K, value V, cost int64) (bool, error)
+	SetSized(key K, value V, ttl time.Duration) (bool, error)
+	Get(key K) (V, bool)
+	Peek(key K) (V, bool)
+	Delete(key K) bool
+	Keys() []K
+	Len() int
+	Clear()
+	Stats() Stats
+	Snapshot(w io.Writer) error
+	Restore(r io.Reader) error
+	Close()
+}
+
+type EvictReason int
+
+const (
+	EvictReasonCapacity EvictReason = iota
+	EvictReasonExpired
+	EvictReasonDeleted
+	EvictReasonCleared
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictReasonCapacity:
+		return "capacity"
+	case EvictReasonExpired:
+		return "expired"
+	case EvictReasonDeleted:
+		return "deleted"
+	case EvictReasonCleared:
+		return "cleared"
+	default:
+		return "unknown"
+	}
+}
+
+type EvictFunc[K comparable, V any] func(key K, value V, reason EvictReason)
+
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64 // items pushed out because the cache was full
+	Expirations uint64 // items removed after their ttl passed
+	Len         int
+	Capacity    int
+	Cost        int64
+	MaxCost     int64
+}
+
+// Sizer returns the cost of a value, e.g. its size in bytes.
+type Sizer[V any] func(value V) int64
+
+type Option[K comparable, V any] func(c *lruCache[K, V])
+
+// WithDefaultTTL sets the lifetime of items added by Set. Non-positive ttl means items never expire.
+func WithDefaultTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(c *lruCache[K, V]) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithJanitor starts a background goroutine that removes expired items every interval.
+// The goroutine is stopped by Close.
+func WithJanitor[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(c *lruCache[K, V]) {
+		c.janitorInterval = interval
+	}
+}
+
+// WithOnEvict sets a callback invoked for every item leaving the cache.
+// The callback is called after the cache lock is released, so it may use the cache.
+func WithOnEvict[K comparable, V any](fn EvictFunc[K, V]) Option[K, V] {
+	return func(c *lruCache[K, V]) {
+		c.onEvict = fn
+	}
+}
+
+// WithMaxCost bounds the total cost of items instead of their count.
+// Items are evicted until the new one fits, capacity still limits the count if it is positive.
+func WithMaxCost[K comparable, V any](maxCost int64) Option[K, V] {
+	return func(c *lruCache[K, V]) {
+		c.maxCost = maxCost
+	}
+}
+
+// WithSizer sets the function computing the cost of values added by Set and SetWithTTL.
+// Without it every such value costs 1.
+func WithSizer[K comparable, V any](sizer Sizer[V]) Option[K, V] {
+	return func(c *lruCache[K, V]) {
+		c.sizer = sizer
+	}
+}
+
+type lruCache[K comparable, V any] struct {
+	mu         sync.Mutex
+	capacity   int
+	policyKind Policy
+	policy     policy[K, V]
+	items      map[K]*cacheItem[K, V]
+
+	maxCost int64
+	cost    int64
+	sizer   Sizer[V]
+
+	defaultTTL      time.Duration
+	janitorInterval time.Duration
+	now             func() time.Time
+
+	codec   Codec
+	onEvict EvictFunc[K, V]
+	evicted []eviction[K, V]
+	stats   Stats
+
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+type cacheItem[K comparable, V any] struct {
+	key       K
+	value     V
+	cost      int64
+	expiresAt time.Time
+
+	// position of the item inside its policy
+	node    *ListItem[*cacheItem[K, V]]
+	segment segment
+	bucket  *ListItem[*lfuBucket[K, V]]
+}
+
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
+
+func (i *cacheItem[K, V]) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
+
+func NewCache[K comparable, V any](capacity int, opts ...Option[K, V]) Cache[K, V] {
+	return newCache(capacity, opts...)
+}
+
+func newCache[K comparable, V any](capacity int, opts ...Option[K, V]) *lruCache[K, V] {
+	c := &lruCache[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*cacheItem[K, V], max(capacity, 0)),
+		codec:    GobCodec,
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.policy = newPolicy[K, V](c.policyKind, max(capacity, 0))
+
+	if c.janitorInterval > 0 {
+		c.stop = make(chan struct{})
+		c.stopped = make(chan struct{})
+		go c.janitor()
+	}
+
+	return c
+}
+
+func (c *lruCache[K, V]) Set(key K, value V) bool {
+	return c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithTTL adds value that expires after ttl. Non-positive ttl means the value never expires.
+// A value the sizer prices above the max cost is dropped together with the old value,
+// use SetSized to get the error.
+func (c *lruCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	wasInCache, _ := c.SetSized(key, value, ttl)
+	return wasInCache
+}
+
+// SetSized adds value like SetWithTTL, but also returns the errors of SetWithCost.
+func (c *lruCache[K, V]) SetSized(key K, value V, ttl time.Duration) (bool, error) {
+	cost := int64(1)
+	if c.sizer != nil {
+		cost = c.sizer(value)
+	}
+
+	return c.set(key, value, cost, ttl)
+}
+
+// SetWithCost adds value taking cost units of the max cost.
+// It returns ErrItemTooLarge if the value can't fit even into the empty cache, the old value is removed then.
+// A negative cost is rejected with ErrNegativeCost and the cache is left as is.
+func (c *lruCache[K, V]) SetWithCost(key K, value V, cost int64) (bool, error) {
+	return c.set(key, value, cost, c.defaultTTL)
+}
+
+func (c *lruCache[K, V]) set(key K, value V, cost int64, ttl time.Duration) (bool, error) {
+	defer c.notifyEvicted()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	now := c.now()
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+
+	return c.store(key, value, cost, expiresAt, now)
+}
+
+// store adds or updates the item. Must be called with c.mu held.
+func (c *lruCache[K, V]) store(key K, value V, cost int64, expiresAt, now time.Time) (bool, error) {
+	if cost < 0 {
+		return false, ErrNegativeCost
+	}
+
+	ci, ok := c.items[key]
+	if ok && c.maxCost > 0 && cost > c.maxCost {
+		c.remove(ci, EvictReasonCapacity)
+		return false, ErrItemTooLarge
+	}
+
+	wasInCache := ok && !ci.expired(now)
+	if wasInCache && (c.maxCost <= 0 || c.cost+cost-ci.cost <= c.maxCost) {
+		c.cost += cost - ci.cost
+		ci.value = value
+		ci.cost = cost
+		ci.expiresAt = expiresAt
+		c.policy.touch(ci)
+		return true, nil
+	}
+
+	switch {
+	case wasInCache:
+		// The grown item makes room like a new one, it is taken out first so the policy can't evict it.
+		delete(c.items, key)
+		c.cost -= ci.cost
+		c.policy.remove(ci)
+	case ok:
+		c.remove(ci, EvictReasonExpired)
+	}
+
+	ci = &cacheItem[K, V]{
+		key:       key,
+		value:     value,
+		cost:      cost,
+		expiresAt: expiresAt,
+	}
+
+	if c.maxCost > 0 && cost > c.maxCost {
+		return false, ErrItemTooLarge
+	}
+
+	if c.capacity <= 0 && c.maxCost <= 0 {
+		c.report(ci, EvictReasonCapacity)
+		return false, nil
+	}
+
+	c.policy.admit(key)
+	for c.full(cost) {
+		c.evict()
+	}
+
+	c.policy.add(ci)
+	c.items[key] = ci
+	c.cost += cost
+
+	return wasInCache, nil
+}
+
+// full reports whether an item of the given cost doesn't fit without evictions.
+func (c *lruCache[K, V]) full(cost int64) bool {
+	if len(c.items) == 0 {
+		return false
+	}
+
+	if c.capacity > 0 && len(c.items) >= c.capacity {
+		return true
+	}
+
+	return c.maxCost > 0 && c.cost+cost > c.maxCost
+}
+
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	defer c.notifyEvicted()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+
+	ci, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return zero, false
+	}
+
+	if ci.expired(c.now()) {
+		c.remove(ci, EvictReasonExpired)
+		c.stats.Misses++
+		return zero, false
+	}
+
+	c.policy.touch(ci)
+	c.stats.Hits++
+	return ci.value, true
+}
+
+// Peek returns the value like Get, but doesn't change the order of items and the hit statistics.
+func (c *lruCache[K, V]) Peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ci, ok := c.items[key]
+	if !ok || ci.expired(c.now()) {
+		var zero V
+		return zero, false
+	}
+
+	return ci.value, true
+}
+
+// Delete removes the key from the cache and reports whether it was present.
+func (c *lruCache[K, V]) Delete(key K) bool {
+	defer c.notifyEvicted()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ci, ok := c.items[key]
+	if !ok {
+		return false
+	}
+
+	expired := ci.expired(c.now())
+	if expired {
+		c.remove(ci, EvictReasonExpired)
+	} else {
+		c.remove(ci, EvictReasonDeleted)
+	}
+
+	return !expired
+}
+
+// Keys returns not expired keys from the most to the least valuable one, for LRU from the most recently used.
+func (c *lruCache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	keys := make([]K, 0, len(c.items))
+	c.policy.each(func(ci *cacheItem[K, V]) {
+		if !ci.expired(now) {
+			keys = append(keys, ci.key)
+		}
+	})
+
+	return keys
+}
+
+// Len returns the number of items, including expired ones not removed yet.
+func (c *lruCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.items)
+}
+
+func (c *lruCache[K, V]) Clear() {
+	defer c.notifyEvicted()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.onEvict != nil {
+		c.policy.each(func(ci *cacheItem[K, V]) {
+			c.report(ci, EvictReasonCleared)
+		})
+	}
+
+	c.policy.clear()
+	c.items = make(map[K]*cacheItem[K, V], max(c.capacity, 0))
+	c.cost = 0
+}
+
+// Close stops the janitor goroutine if it was started. The cache stays usable after Close.
+func (c *lruCache[K, V]) Close() {
+	if c.stop == nil {
+		return
+	}
+
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		<-c.stopped
+	})
+}
+
+func (c *lruCache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Len = len(c.items)
+	stats.Capacity = c.capacity
+	stats.Cost = c.cost
+	stats.MaxCost = c.maxCost
+	return stats
+}
+
+func (c *lruCache[K, V]) remove(ci *cacheItem[K, V], reason EvictReason) {
+	delete(c.items, ci.key)
+	c.cost -= ci.cost
+	c.policy.remove(ci)
+	c.report(ci, reason)
+}
+
+// evict drops the item chosen by the policy to make room for a new one.
+func (c *lruCache[K, V]) evict() {
+	ci := c.policy.evict()
+	delete(c.items, ci.key)
+	c.cost -= ci.cost
+	c.report(ci, EvictReasonCapacity)
+}
+
+// report counts the eviction and queues it for the callback. Must be called with c.mu held.
+func (c *lruCache[K, V]) report(ci *cacheItem[K, V], reason EvictReason) {
+	switch reason {
+	case EvictReasonCapacity:
+		c.stats.Evictions++
+	case EvictReasonExpired:
+		c.stats.Expirations++
+	case EvictReasonDeleted, EvictReasonCleared:
+	}
+
+	if c.onEvict != nil {
+		c.evicted = append(c.evicted, eviction[K, V]{key: ci.key, value: ci.value, reason: reason})
+	}
+}
+
+func (c *lruCache[K, V]) notifyEvicted() {
+	if c.onEvict == nil {
+		return
+	}
+
+	c.mu.Lock()
+	evicted := c.evicted
+	c.evicted = nil
+	c.mu.Unlock()
+
+	for _, e := range evicted {
+		c.onEvict(e.key, e.value, e.reason)
+	}
+}
+
+func (c *lruCache[K, V]) removeExpired() {
+	defer c.notifyEvicted()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for _, ci := range c.items {
+		if ci.expired(now) {
+			c.remove(ci, EvictReasonExpired)
+		}
+	}
+}
+
+func (c *lruCache[K, V]) janitor() {
+	defer close(c.stopped)
+
+	ticker := time.NewTicker(c.janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.removeExpired()
+		}
+	}
+}
@@ -0,0 +1,556 @@
+package lru
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var policies = []Policy{PolicyLRU, PolicyLFU, Policy2Q, PolicyARC}
+
+type cacheFactory func(capacity int, opts ...Option[string, int]) Cache[string, int]
+
+// forEachPolicy runs the same scenarios against every eviction policy.
+func forEachPolicy(t *testing.T, test func(t *testing.T, newCache cacheFactory)) {
+	t.Helper()
+
+	for _, p := range policies {
+		p := p
+		t.Run(p.String(), func(t *testing.T) {
+			test(t, func(capacity int, opts ...Option[string, int]) Cache[string, int] {
+				return NewCache(capacity, append(opts, WithPolicy[string, int](p))...)
+			})
+		})
+	}
+}
+
+func withClock(c Cache[string, int], now *time.Time) {
+	c.(*lruCache[string, int]).now = func() time.Time { return *now }
+}
+
+func TestCache(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache cacheFactory) {
+		t.Run("empty cache", func(t *testing.T) {
+			c := newCache(10)
+
+			_, ok := c.Get("aaa")
+			require.False(t, ok)
+
+			_, ok = c.Get("bbb")
+			require.False(t, ok)
+		})
+
+		t.Run("simple", func(t *testing.T) {
+			c := newCache(5)
+
+			wasInCache := c.Set("aaa", 100)
+			require.False(t, wasInCache)
+
+			wasInCache = c.Set("bbb", 200)
+			require.False(t, wasInCache)
+
+			val, ok := c.Get("aaa")
+			require.True(t, ok)
+			require.Equal(t, 100, val)
+
+			wasInCache = c.Set("aaa", 300)
+			require.True(t, wasInCache)
+
+			val, ok = c.Get("aaa")
+			require.True(t, ok)
+			require.Equal(t, 300, val)
+
+			val, ok = c.Get("ccc")
+			require.False(t, ok)
+			require.Zero(t, val)
+		})
+
+		t.Run("purge logic", func(t *testing.T) {
+			c := newCache(3)
+
+			c.Set("key1", 1)
+			c.Set("key2", 2)
+			c.Set("key3", 3)
+
+			// first element should be removed
+			wasInCache := c.Set("key4", 4)
+			require.False(t, wasInCache)
+
+			_, ok := c.Get("key1")
+			require.False(t, ok)
+
+			val, ok := c.Get("key4")
+			require.True(t, ok)
+			require.Equal(t, 4, val)
+		})
+
+		t.Run("capacity is never exceeded", func(t *testing.T) {
+			c := newCache(10)
+
+			for i := 0; i < 1000; i++ {
+				key := strconv.Itoa(rand.Intn(50))
+				if i%3 == 0 {
+					c.Get(key)
+				} else {
+					c.Set(key, i)
+				}
+				require.LessOrEqual(t, c.Stats().Len, 10)
+			}
+		})
+
+		t.Run("zero capacity", func(t *testing.T) {
+			c := newCache(0)
+
+			c.Set("aaa", 100)
+			_, ok := c.Get("aaa")
+			require.False(t, ok)
+			require.Equal(t, uint64(1), c.Stats().Evictions)
+		})
+
+		t.Run("peek", func(t *testing.T) {
+			c := newCache(3)
+
+			c.Set("key1", 1)
+			c.Set("key2", 2)
+			c.Set("key3", 3)
+
+			val, ok := c.Peek("key1")
+			require.True(t, ok)
+			require.Equal(t, 1, val)
+
+			_, ok = c.Peek("key4")
+			require.False(t, ok)
+			require.Equal(t, Stats{Len: 3, Capacity: 3, Cost: 3}, c.Stats())
+
+			// peek doesn't save key1 from eviction
+			c.Set("key4", 4)
+			_, ok = c.Peek("key1")
+			require.False(t, ok)
+		})
+
+		t.Run("keys and len", func(t *testing.T) {
+			c := newCache(3)
+			require.Empty(t, c.Keys())
+			require.Equal(t, 0, c.Len())
+
+			c.Set("key1", 1)
+			c.Set("key2", 2)
+			c.Set("key3", 3)
+			c.Set("key4", 4)
+
+			require.ElementsMatch(t, []string{"key2", "key3", "key4"}, c.Keys())
+			require.Equal(t, 3, c.Len())
+		})
+
+		t.Run("delete", func(t *testing.T) {
+			c := newCache(2)
+
+			c.Set("key1", 1)
+			require.True(t, c.Delete("key1"))
+			require.False(t, c.Delete("key1"))
+
+			_, ok := c.Get("key1")
+			require.False(t, ok)
+
+			c.Set("key2", 2)
+			c.Set("key3", 3)
+			require.Equal(t, 2, c.Stats().Len)
+		})
+
+		t.Run("clear", func(t *testing.T) {
+			c := newCache(2)
+
+			c.Set("key1", 1)
+			c.Set("key2", 2)
+			c.Get("key1")
+
+			c.Clear()
+
+			_, ok := c.Get("key1")
+			require.False(t, ok)
+
+			_, ok = c.Get("key2")
+			require.False(t, ok)
+
+			c.Set("key3", 3)
+			val, ok := c.Get("key3")
+			require.True(t, ok)
+			require.Equal(t, 3, val)
+		})
+	})
+}
+
+func TestCacheTTL(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache cacheFactory) {
+		t.Run("expired item is a miss", func(t *testing.T) {
+			c := newCache(5)
+			now := time.Now()
+			withClock(c, &now)
+
+			c.SetWithTTL("aaa", 100, time.Minute)
+			c.Set("bbb", 200)
+
+			val, ok := c.Get("aaa")
+			require.True(t, ok)
+			require.Equal(t, 100, val)
+
+			now = now.Add(time.Minute)
+
+			val, ok = c.Get("aaa")
+			require.False(t, ok)
+			require.Zero(t, val)
+			require.Equal(t, 1, c.Stats().Len)
+			require.NotContains(t, c.(*lruCache[string, int]).items, "aaa")
+
+			val, ok = c.Get("bbb")
+			require.True(t, ok)
+			require.Equal(t, 200, val)
+		})
+
+		t.Run("default ttl", func(t *testing.T) {
+			c := newCache(5, WithDefaultTTL[string, int](time.Second))
+			now := time.Now()
+			withClock(c, &now)
+
+			c.Set("aaa", 100)
+			c.SetWithTTL("bbb", 200, 0)
+
+			now = now.Add(time.Hour)
+
+			_, ok := c.Get("aaa")
+			require.False(t, ok)
+
+			val, ok := c.Get("bbb")
+			require.True(t, ok)
+			require.Equal(t, 200, val)
+		})
+
+		t.Run("set over expired item", func(t *testing.T) {
+			c := newCache(5)
+			now := time.Now()
+			withClock(c, &now)
+
+			wasInCache := c.SetWithTTL("aaa", 100, time.Second)
+			require.False(t, wasInCache)
+
+			now = now.Add(time.Second)
+
+			wasInCache = c.SetWithTTL("aaa", 300, time.Second)
+			require.False(t, wasInCache)
+
+			val, ok := c.Get("aaa")
+			require.True(t, ok)
+			require.Equal(t, 300, val)
+		})
+
+		t.Run("janitor removes expired items", func(t *testing.T) {
+			c := newCache(5, WithJanitor[string, int](10*time.Millisecond))
+			defer c.Close()
+
+			c.SetWithTTL("aaa", 100, 20*time.Millisecond)
+			c.Set("bbb", 200)
+
+			require.Eventually(t, func() bool {
+				return c.Stats().Len == 1
+			}, time.Second, 10*time.Millisecond)
+
+			val, ok := c.Get("bbb")
+			require.True(t, ok)
+			require.Equal(t, 200, val)
+		})
+	})
+
+	t.Run("close is idempotent", func(t *testing.T) {
+		c := NewCache(5, WithJanitor[string, int](time.Millisecond))
+		c.Close()
+		c.Close()
+
+		NewCache[string, int](5).Close()
+	})
+}
+
+func TestCacheTypedKeys(t *testing.T) {
+	t.Run("int keys", func(t *testing.T) {
+		c := NewCache[int, string](2)
+
+		c.Set(1, "one")
+		c.Set(2, "two")
+		c.Get(1)
+		c.Set(3, "three")
+
+		val, ok := c.Get(1)
+		require.True(t, ok)
+		require.Equal(t, "one", val)
+
+		val, ok = c.Get(2)
+		require.False(t, ok)
+		require.Zero(t, val)
+	})
+
+	t.Run("struct keys", func(t *testing.T) {
+		type point struct {
+			x, y int
+		}
+
+		c := NewCache[point, []int](3)
+
+		wasInCache := c.Set(point{1, 2}, []int{1, 2})
+		require.False(t, wasInCache)
+
+		wasInCache = c.Set(point{1, 2}, []int{3})
+		require.True(t, wasInCache)
+
+		val, ok := c.Get(point{1, 2})
+		require.True(t, ok)
+		require.Equal(t, []int{3}, val)
+
+		_, ok = c.Get(point{2, 1})
+		require.False(t, ok)
+	})
+}
+
+func TestCacheEviction(t *testing.T) {
+	type evicted struct {
+		key    string
+		value  int
+		reason EvictReason
+	}
+
+	forEachPolicy(t, func(t *testing.T, newCache cacheFactory) {
+		t.Run("on evict reasons", func(t *testing.T) {
+			var got []evicted
+			c := newCache(2, WithOnEvict(func(key string, value int, reason EvictReason) {
+				got = append(got, evicted{key: key, value: value, reason: reason})
+			}))
+			now := time.Now()
+			withClock(c, &now)
+
+			c.Set("key1", 1)
+			c.Set("key2", 2)
+			c.Set("key3", 3) // key1 is pushed out
+			require.True(t, c.Delete("key2"))
+			require.False(t, c.Delete("key2"))
+
+			c.SetWithTTL("key4", 4, time.Second)
+			now = now.Add(time.Second)
+			_, ok := c.Get("key4")
+			require.False(t, ok)
+
+			c.Clear()
+
+			require.Equal(t, []evicted{
+				{key: "key1", value: 1, reason: EvictReasonCapacity},
+				{key: "key2", value: 2, reason: EvictReasonDeleted},
+				{key: "key4", value: 4, reason: EvictReasonExpired},
+				{key: "key3", value: 3, reason: EvictReasonCleared},
+			}, got)
+		})
+
+		t.Run("callback may use cache", func(t *testing.T) {
+			var c Cache[string, int]
+			var stillInCache bool
+			c = newCache(1, WithOnEvict(func(key string, _ int, _ EvictReason) {
+				_, stillInCache = c.Get(key)
+			}))
+
+			c.Set("key1", 1)
+			c.Set("key2", 2)
+
+			require.False(t, stillInCache)
+			require.Equal(t, uint64(1), c.Stats().Misses)
+		})
+
+		t.Run("stats", func(t *testing.T) {
+			c := newCache(2)
+			now := time.Now()
+			withClock(c, &now)
+
+			c.Set("key1", 1)
+			c.Set("key2", 2)
+			c.Set("key3", 3)
+			c.SetWithTTL("key4", 4, time.Second)
+
+			c.Get("key1")
+			c.Get("key3")
+			c.Get("key4")
+			now = now.Add(time.Second)
+			c.Get("key4")
+
+			require.Equal(t, Stats{
+				Hits:        2,
+				Misses:      2,
+				Evictions:   2,
+				Expirations: 1,
+				Len:         1,
+				Capacity:    2,
+				Cost:        1,
+			}, c.Stats())
+		})
+	})
+}
+
+func TestCacheMultithreading(t *testing.T) {
+	forEachPolicy(t, func(_ *testing.T, newCache cacheFactory) {
+		c := newCache(10)
+		wg := &sync.WaitGroup{}
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100_000; i++ {
+				c.Set(strconv.Itoa(i), i)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100_000; i++ {
+				c.Get(strconv.Itoa(rand.Intn(100_000)))
+			}
+		}()
+
+		wg.Wait()
+	})
+}
+
+func TestCacheCost(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache cacheFactory) {
+		t.Run("evict until fits", func(t *testing.T) {
+			c := newCache(0, WithMaxCost[string, int](10))
+
+			for _, key := range []string{"key1", "key2", "key3"} {
+				wasInCache, err := c.SetWithCost(key, 1, 4)
+				require.NoError(t, err)
+				require.False(t, wasInCache)
+			}
+
+			_, ok := c.Get("key1")
+			require.False(t, ok)
+
+			stats := c.Stats()
+			require.Equal(t, 2, stats.Len)
+			require.Equal(t, int64(8), stats.Cost)
+			require.Equal(t, int64(10), stats.MaxCost)
+			require.Equal(t, uint64(1), stats.Evictions)
+		})
+
+		t.Run("item larger than max cost", func(t *testing.T) {
+			c := newCache(0, WithMaxCost[string, int](10))
+
+			c.SetWithCost("key1", 1, 5)
+
+			wasInCache, err := c.SetWithCost("key2", 2, 11)
+			require.ErrorIs(t, err, ErrItemTooLarge)
+			require.False(t, wasInCache)
+
+			_, ok := c.Get("key2")
+			require.False(t, ok)
+
+			val, ok := c.Get("key1")
+			require.True(t, ok)
+			require.Equal(t, 1, val)
+
+			// the old value must not survive a rejected update
+			_, err = c.SetWithCost("key1", 11, 11)
+			require.ErrorIs(t, err, ErrItemTooLarge)
+
+			_, ok = c.Get("key1")
+			require.False(t, ok)
+			require.Equal(t, int64(0), c.Stats().Cost)
+		})
+
+		t.Run("sizer", func(t *testing.T) {
+			c := newCache(0,
+				WithMaxCost[string, int](10),
+				WithSizer[string, int](func(v int) int64 { return int64(v) }),
+			)
+
+			c.Set("key1", 6)
+			c.Set("key2", 5)
+
+			_, ok := c.Get("key1")
+			require.False(t, ok)
+
+			wasInCache := c.Set("key3", 100)
+			require.False(t, wasInCache)
+			require.Equal(t, int64(5), c.Stats().Cost)
+		})
+
+		t.Run("cost never exceeds max cost", func(t *testing.T) {
+			c := newCache(0, WithMaxCost[string, int](100))
+
+			for i := 0; i < 1000; i++ {
+				c.SetWithCost(strconv.Itoa(rand.Intn(50)), i, int64(rand.Intn(30)+1))
+				require.LessOrEqual(t, c.Stats().Cost, int64(100))
+			}
+		})
+
+		t.Run("capacity limits count", func(t *testing.T) {
+			c := newCache(2, WithMaxCost[string, int](100))
+
+			c.SetWithCost("key1", 1, 1)
+			c.SetWithCost("key2", 2, 1)
+			c.SetWithCost("key3", 3, 1)
+
+			require.Equal(t, 2, c.Stats().Len)
+			require.Equal(t, int64(2), c.Stats().Cost)
+		})
+
+		t.Run("update changes cost", func(t *testing.T) {
+			c := newCache(0, WithMaxCost[string, int](10))
+
+			c.SetWithCost("key1", 1, 3)
+			c.SetWithCost("key2", 2, 3)
+
+			wasInCache, err := c.SetWithCost("key1", 11, 8)
+			require.NoError(t, err)
+			require.True(t, wasInCache)
+
+			val, ok := c.Get("key1")
+			require.True(t, ok)
+			require.Equal(t, 11, val)
+
+			_, ok = c.Get("key2")
+			require.False(t, ok)
+			require.Equal(t, []string{"key1"}, c.Keys())
+			require.Equal(t, int64(8), c.Stats().Cost)
+		})
+
+		t.Run("negative cost", func(t *testing.T) {
+			c := newCache(0, WithMaxCost[string, int](10))
+
+			c.SetWithCost("key1", 1, 5)
+
+			wasInCache, err := c.SetWithCost("key1", 2, -100)
+			require.ErrorIs(t, err, ErrNegativeCost)
+			require.False(t, wasInCache)
+
+			val, ok := c.Get("key1")
+			require.True(t, ok)
+			require.Equal(t, 1, val)
+			require.Equal(t, int64(5), c.Stats().Cost)
+		})
+
+		t.Run("sized item larger than max cost", func(t *testing.T) {
+			c := newCache(0,
+				WithMaxCost[string, int](10),
+				WithSizer[string, int](func(v int) int64 { return int64(v) }),
+			)
+
+			wasInCache, err := c.SetSized("key1", 5, 0)
+			require.NoError(t, err)
+			require.False(t, wasInCache)
+
+			wasInCache, err = c.SetSized("key1", 100, 0)
+			require.ErrorIs(t, err, ErrItemTooLarge)
+			require.False(t, wasInCache)
+
+			_, ok := c.Get("key1")
+			require.False(t, ok)
+		})
+	})
+}
@@ -0,0 +1,78 @@
+package lru
+
+// lfuPolicy keeps items in buckets of equal access frequency.
+// Buckets are ordered by frequency from the front, items inside a bucket by recency.
+type lfuPolicy[K comparable, V any] struct {
+	buckets List[*lfuBucket[K, V]]
+}
+
+type lfuBucket[K comparable, V any] struct {
+	freq  int
+	items List[*cacheItem[K, V]]
+}
+
+func newLFUPolicy[K comparable, V any]() *lfuPolicy[K, V] {
+	return &lfuPolicy[K, V]{
+		buckets: NewList[*lfuBucket[K, V]](),
+	}
+}
+
+func newLFUBucket[K comparable, V any](freq int) *lfuBucket[K, V] {
+	return &lfuBucket[K, V]{
+		freq:  freq,
+		items: NewList[*cacheItem[K, V]](),
+	}
+}
+
+func (p *lfuPolicy[K, V]) admit(K) {}
+
+func (p *lfuPolicy[K, V]) add(ci *cacheItem[K, V]) {
+	front := p.buckets.Front()
+	if front == nil || front.Value.freq != 1 {
+		front = p.buckets.PushFront(newLFUBucket[K, V](1))
+	}
+
+	ci.bucket = front
+	ci.node = front.Value.items.PushFront(ci)
+}
+
+func (p *lfuPolicy[K, V]) touch(ci *cacheItem[K, V]) {
+	cur := ci.bucket
+	next := cur.Next
+	if next == nil || next.Value.freq != cur.Value.freq+1 {
+		next = p.buckets.InsertAfter(newLFUBucket[K, V](cur.Value.freq+1), cur)
+	}
+
+	p.remove(ci)
+	ci.bucket = next
+	ci.node = next.Value.items.PushFront(ci)
+}
+
+func (p *lfuPolicy[K, V]) remove(ci *cacheItem[K, V]) {
+	bucket := ci.bucket
+	bucket.Value.items.Remove(ci.node)
+	if bucket.Value.items.Len() == 0 {
+		p.buckets.Remove(bucket)
+	}
+}
+
+func (p *lfuPolicy[K, V]) evict() *cacheItem[K, V] {
+	front := p.buckets.Front()
+	if front == nil {
+		return nil
+	}
+
+	ci := front.Value.items.Back().Value
+	p.remove(ci)
+	return ci
+}
+
+func (p *lfuPolicy[K, V]) clear() {
+	p.buckets = NewList[*lfuBucket[K, V]]()
+}
+
+func (p *lfuPolicy[K, V]) each(fn func(ci *cacheItem[K, V])) {
+	for bucket := p.buckets.Back(); bucket != nil; bucket = bucket.Prev {
+		eachItem(bucket.Value.items, fn)
+	}
+}
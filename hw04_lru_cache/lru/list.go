@@ -0,0 +1,212 @@
+package lru
+
+import "iter"
+
+type List[T any] interface {
+	Len() int
+	Front() *ListItem[T]
+	Back() *ListItem[T]
+	PushFront(v T) *ListItem[T]
+	PushBack(v T) *ListItem[T]
+	InsertBefore(v T, mark *ListItem[T]) *ListItem[T]
+	InsertAfter(v T, mark *ListItem[T]) *ListItem[T]
+	PushFrontList(other List[T])
+	Remove(i *ListItem[T])
+	MoveToFront(i *ListItem[T])
+	MoveToBack(i *ListItem[T])
+	All() iter.Seq[T]
+	Backward() iter.Seq[T]
+}
+
+type ListItem[T any] struct {
+	Value T
+	Next  *ListItem[T]
+	Prev  *ListItem[T]
+	list  *list[T] // nil after the item is removed
+}
+
+type list[T any] struct {
+	front *ListItem[T]
+	back  *ListItem[T]
+	len   int
+}
+
+func NewList[T any]() List[T] {
+	return new(list[T])
+}
+
+func (l *list[T]) Len() int {
+	return l.len
+}
+
+func (l *list[T]) Front() *ListItem[T] {
+	return l.front
+}
+
+func (l *list[T]) Back() *ListItem[T] {
+	return l.back
+}
+
+func (l *list[T]) PushFront(v T) *ListItem[T] {
+	item := &ListItem[T]{
+		Value: v,
+		list:  l,
+	}
+
+	if l.len == 0 {
+		l.front = item
+		l.back = item
+	} else {
+		item.Next = l.front
+		l.front.Prev = item
+		l.front = item
+	}
+
+	l.len++
+	return item
+}
+
+func (l *list[T]) PushBack(v T) *ListItem[T] {
+	item := &ListItem[T]{
+		Value: v,
+		list:  l,
+	}
+
+	if l.len == 0 {
+		l.front = item
+		l.back = item
+	} else {
+		item.Prev = l.back
+		l.back.Next = item
+		l.back = item
+	}
+
+	l.len++
+	return item
+}
+
+// InsertBefore inserts v before mark. It returns nil and doesn't change the list if mark isn't its item.
+func (l *list[T]) InsertBefore(v T, mark *ListItem[T]) *ListItem[T] {
+	if !l.owns(mark) {
+		return nil
+	}
+
+	if mark == l.front {
+		return l.PushFront(v)
+	}
+
+	return l.InsertAfter(v, mark.Prev)
+}
+
+// InsertAfter inserts v after mark. It returns nil and doesn't change the list if mark isn't its item.
+func (l *list[T]) InsertAfter(v T, mark *ListItem[T]) *ListItem[T] {
+	if !l.owns(mark) {
+		return nil
+	}
+
+	if mark == l.back {
+		return l.PushBack(v)
+	}
+
+	item := &ListItem[T]{
+		Value: v,
+		Prev:  mark,
+		Next:  mark.Next,
+		list:  l,
+	}
+	mark.Next.Prev = item
+	mark.Next = item
+
+	l.len++
+	return item
+}
+
+// PushFrontList inserts a copy of other at the front of the list. other may be the list itself.
+func (l *list[T]) PushFrontList(other List[T]) {
+	n := other.Len()
+	for i := other.Back(); n > 0; i, n = i.Prev, n-1 {
+		l.PushFront(i.Value)
+	}
+}
+
+// Remove unlinks i and clears its Next and Prev. Items which aren't in the list, like already removed ones,
+// are ignored, so a stale handle can't corrupt the list.
+func (l *list[T]) Remove(i *ListItem[T]) {
+	if !l.owns(i) {
+		return
+	}
+
+	l.unlink(i)
+	i.list = nil
+	l.len--
+}
+
+func (l *list[T]) MoveToFront(i *ListItem[T]) {
+	if !l.owns(i) || i == l.front {
+		return
+	}
+
+	l.unlink(i)
+	i.Next = l.front
+	l.front.Prev = i
+	l.front = i
+}
+
+func (l *list[T]) MoveToBack(i *ListItem[T]) {
+	if !l.owns(i) || i == l.back {
+		return
+	}
+
+	l.unlink(i)
+	i.Prev = l.back
+	l.back.Next = i
+	l.back = i
+}
+
+func (l *list[T]) owns(i *ListItem[T]) bool {
+	return i != nil && i.list == l
+}
+
+// unlink takes i out of the chain of items without changing the length.
+func (l *list[T]) unlink(i *ListItem[T]) {
+	if i.Prev != nil {
+		i.Prev.Next = i.Next
+	} else {
+		l.front = i.Next
+	}
+
+	if i.Next != nil {
+		i.Next.Prev = i.Prev
+	} else {
+		l.back = i.Prev
+	}
+
+	i.Next = nil
+	i.Prev = nil
+}
+
+// All iterates over values from front to back. The current item may be removed during the iteration.
+func (l *list[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := l.front; i != nil; {
+			next := i.Next
+			if !yield(i.Value) {
+				return
+			}
+			i = next
+		}
+	}
+}
+
+// Backward iterates over values from back to front. The current item may be removed during the iteration.
+func (l *list[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := l.back; i != nil; {
+			prev := i.Prev
+			if !yield(i.Value) {
+				return
+			}
+			i = prev
+		}
+	}
+}
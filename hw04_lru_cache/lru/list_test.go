@@ -0,0 +1,195 @@
+package lru
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestList(t *testing.T) {
+	t.Run("typed values", func(t *testing.T) {
+		l := NewList[string]()
+
+		l.PushBack("b")  // [b]
+		l.PushFront("a") // [a, b]
+		c := l.PushBack("c")
+		l.MoveToFront(c) // [c, a, b]
+		l.Remove(l.Back())
+
+		require.Equal(t, 2, l.Len())
+		require.Equal(t, "c", l.Front().Value)
+		require.Equal(t, "a", l.Back().Value)
+	})
+
+	t.Run("pointer values", func(t *testing.T) {
+		type item struct {
+			n int
+		}
+
+		l := NewList[*item]()
+		l.PushBack(&item{1})
+		l.PushBack(&item{2})
+
+		l.Front().Value.n = 10
+
+		elems := make([]int, 0, l.Len())
+		for i := l.Front(); i != nil; i = i.Next {
+			elems = append(elems, i.Value.n)
+		}
+		require.Equal(t, []int{10, 2}, elems)
+	})
+	t.Run("insert after", func(t *testing.T) {
+		l := NewList[int]()
+
+		one := l.PushBack(1)
+		l.InsertAfter(2, one) // [1, 2]
+		l.InsertAfter(3, l.Back())
+		l.InsertAfter(4, one) // [1, 4, 2, 3]
+
+		require.Equal(t, 4, l.Len())
+		require.Equal(t, []int{1, 4, 2, 3}, listValues(l))
+		require.Equal(t, 3, l.Back().Value)
+		require.Equal(t, 2, l.Back().Prev.Value)
+	})
+
+	t.Run("insert before", func(t *testing.T) {
+		l := NewList[int]()
+
+		three := l.PushBack(3)
+		l.InsertBefore(1, three) // [1, 3]
+		l.InsertBefore(2, three) // [1, 2, 3]
+		l.InsertBefore(0, l.Front())
+
+		require.Equal(t, []int{0, 1, 2, 3}, listValues(l))
+		require.Equal(t, 0, l.Front().Value)
+		require.Equal(t, 1, l.Front().Next.Value)
+		require.Equal(t, 0, l.Front().Next.Prev.Value)
+	})
+
+	t.Run("move to back", func(t *testing.T) {
+		l := NewList[int]()
+
+		l.PushBack(1)
+		l.PushBack(2)
+		l.PushBack(3)
+
+		l.MoveToBack(l.Back())
+		require.Equal(t, []int{1, 2, 3}, listValues(l))
+
+		l.MoveToBack(l.Front())
+		require.Equal(t, []int{2, 3, 1}, listValues(l))
+		require.Equal(t, []int{1, 3, 2}, slices.Collect(l.Backward()))
+
+		l.MoveToBack(l.Front().Next)
+		require.Equal(t, []int{2, 1, 3}, listValues(l))
+		require.Equal(t, []int{3, 1, 2}, slices.Collect(l.Backward()))
+		require.Nil(t, l.Front().Prev)
+		require.Nil(t, l.Back().Next)
+	})
+
+	t.Run("iterators", func(t *testing.T) {
+		l := NewList[int]()
+		for i := 1; i <= 5; i++ {
+			l.PushBack(i)
+		}
+
+		require.Equal(t, []int{1, 2, 3, 4, 5}, slices.Collect(l.All()))
+		require.Equal(t, []int{5, 4, 3, 2, 1}, slices.Collect(l.Backward()))
+
+		var firstTwo []int
+		for v := range l.All() {
+			if len(firstTwo) == 2 {
+				break
+			}
+			firstTwo = append(firstTwo, v)
+		}
+		require.Equal(t, []int{1, 2}, firstTwo)
+
+		require.Empty(t, slices.Collect(NewList[int]().All()))
+	})
+
+	t.Run("push front list", func(t *testing.T) {
+		l := NewList[int]()
+		l.PushBack(3)
+		l.PushBack(4)
+
+		other := NewList[int]()
+		other.PushBack(1)
+		other.PushBack(2)
+
+		l.PushFrontList(other)
+		require.Equal(t, []int{1, 2, 3, 4}, listValues(l))
+		require.Equal(t, []int{1, 2}, listValues(other))
+
+		l.PushFrontList(l)
+		require.Equal(t, []int{1, 2, 3, 4, 1, 2, 3, 4}, listValues(l))
+		require.Equal(t, 8, l.Len())
+
+		l.PushFrontList(NewList[int]())
+		require.Equal(t, 8, l.Len())
+	})
+}
+
+func TestListStaleItems(t *testing.T) {
+	t.Run("removed item is detached", func(t *testing.T) {
+		l := NewList[int]()
+		l.PushBack(1)
+		middle := l.PushBack(2)
+		l.PushBack(3)
+
+		l.Remove(middle)
+		require.Nil(t, middle.Next)
+		require.Nil(t, middle.Prev)
+		require.Equal(t, []int{1, 3}, listValues(l))
+
+		last := l.Front()
+		l.Remove(l.Back())
+		l.Remove(last)
+		require.Nil(t, last.Next)
+		require.Nil(t, last.Prev)
+	})
+
+	t.Run("stale handles are ignored", func(t *testing.T) {
+		l := NewList[int]()
+		only := l.PushBack(1)
+		l.Remove(only)
+		l.Remove(only)
+		require.Zero(t, l.Len())
+		require.Nil(t, l.Front())
+		require.Nil(t, l.Back())
+
+		first := l.PushBack(1)
+		removed := l.PushBack(2)
+		l.PushBack(3)
+		l.Remove(removed)
+		l.Remove(removed)
+		require.Equal(t, 2, l.Len())
+		require.Equal(t, []int{1, 3}, listValues(l))
+
+		require.Nil(t, l.InsertAfter(4, removed))
+		require.Nil(t, l.InsertBefore(4, removed))
+		l.MoveToFront(removed)
+		l.MoveToBack(removed)
+		require.Equal(t, []int{1, 3}, listValues(l))
+		require.Equal(t, 2, l.Len())
+
+		other := NewList[int]()
+		foreign := other.PushBack(10)
+		l.Remove(foreign)
+		l.MoveToBack(first)
+		l.MoveToFront(foreign)
+		require.Nil(t, l.InsertAfter(4, foreign))
+		require.Equal(t, []int{3, 1}, listValues(l))
+		require.Equal(t, []int{10}, listValues(other))
+		require.Equal(t, 1, other.Len())
+	})
+}
+
+func listValues[T any](l List[T]) []T {
+	values := make([]T, 0, l.Len())
+	for i := l.Front(); i != nil; i = i.Next {
+		values = append(values, i.Value)
+	}
+	return values
+}
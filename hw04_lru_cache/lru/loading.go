@@ -0,0 +1,135 @@
+package lru
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+type LoadingOption[K comparable, V any] func(l *LoadingCache[K, V])
+
+// WithErrorTTL makes GetOrLoad return the last loader error for the key during ttl instead of loading again.
+// By default errors are not cached.
+func WithErrorTTL[K comparable, V any](ttl time.Duration) LoadingOption[K, V] {
+	return func(l *LoadingCache[K, V]) {
+		l.errorTTL = ttl
+	}
+}
+
+// LoadingCache fills the cache on misses and makes concurrent misses of the same key share a single load.
+type LoadingCache[K comparable, V any] struct {
+	Cache[K, V]
+
+	mu       sync.Mutex
+	calls    map[K]*call[V]
+	failures map[K]failure
+	errorTTL time.Duration
+	now      func() time.Time
+}
+
+// call is a load in progress.
+type call[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type failure struct {
+	err       error
+	expiresAt time.Time
+}
+
+func NewLoadingCache[K comparable, V any](c Cache[K, V], opts ...LoadingOption[K, V]) *LoadingCache[K, V] {
+	l := &LoadingCache[K, V]{
+		Cache:    c,
+		calls:    make(map[K]*call[V]),
+		failures: make(map[K]failure),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// GetOrLoad returns the cached value or calls loader and caches its result.
+// The loader runs with a context that keeps the values of ctx and is cancelled
+// only when every caller waiting for it has given up. Loader errors are returned but not cached.
+func (l *LoadingCache[K, V]) GetOrLoad(ctx context.Context, key K, loader LoaderFunc[K, V]) (V, error) {
+	if value, ok := l.Get(key); ok {
+		return value, nil
+	}
+
+	var zero V
+
+	l.mu.Lock()
+	if f, ok := l.failures[key]; ok {
+		if l.now().Before(f.expiresAt) {
+			l.mu.Unlock()
+			return zero, f.err
+		}
+		delete(l.failures, key)
+	}
+
+	c, ok := l.calls[key]
+	if !ok {
+		c = l.load(ctx, key, loader)
+	}
+	c.waiters++
+	l.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		l.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			if l.calls[key] == c {
+				delete(l.calls, key)
+			}
+		}
+		l.mu.Unlock()
+
+		return zero, ctx.Err()
+	}
+}
+
+// load starts loader in a separate goroutine. Must be called with l.mu held.
+func (l *LoadingCache[K, V]) load(ctx context.Context, key K, loader LoaderFunc[K, V]) *call[V] {
+	loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &call[V]{
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	l.calls[key] = c
+
+	go func() {
+		defer cancel()
+
+		c.value, c.err = loader(loadCtx, key)
+		if c.err == nil {
+			l.Set(key, c.value)
+		}
+
+		l.mu.Lock()
+		if l.calls[key] == c {
+			delete(l.calls, key)
+		}
+		if c.err != nil && l.errorTTL > 0 && loadCtx.Err() == nil {
+			l.failures[key] = failure{err: c.err, expiresAt: l.now().Add(l.errorTTL)}
+		}
+		l.mu.Unlock()
+
+		close(c.done)
+	}()
+
+	return c
+}
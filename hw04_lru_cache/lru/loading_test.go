@@ -0,0 +1,186 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadingCache(t *testing.T) {
+	t.Run("load on miss", func(t *testing.T) {
+		l := NewLoadingCache(NewCache[string, int](10))
+
+		var calls int32
+		loader := func(_ context.Context, key string) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			return len(key), nil
+		}
+
+		for i := 0; i < 3; i++ {
+			val, err := l.GetOrLoad(context.Background(), "aaa", loader)
+			require.NoError(t, err)
+			require.Equal(t, 3, val)
+		}
+		require.Equal(t, int32(1), calls)
+
+		val, ok := l.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 3, val)
+	})
+
+	t.Run("concurrent misses share one load", func(t *testing.T) {
+		l := NewLoadingCache(NewCache[string, int](10))
+
+		var calls int32
+		release := make(chan struct{})
+		loader := func(_ context.Context, _ string) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return 42, nil
+		}
+
+		const callers = 10
+		wg := sync.WaitGroup{}
+		wg.Add(callers)
+		for i := 0; i < callers; i++ {
+			go func() {
+				defer wg.Done()
+				val, err := l.GetOrLoad(context.Background(), "aaa", loader)
+				require.NoError(t, err)
+				require.Equal(t, 42, val)
+			}()
+		}
+
+		require.Eventually(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			c, ok := l.calls["aaa"]
+			return ok && c.waiters == callers
+		}, time.Second, time.Millisecond)
+
+		close(release)
+		wg.Wait()
+		require.Equal(t, int32(1), calls)
+	})
+}
+
+func TestLoadingCacheErrors(t *testing.T) {
+	errLoad := errors.New("load failed")
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		l := NewLoadingCache(NewCache[string, int](10))
+
+		fail := true
+		loader := func(_ context.Context, _ string) (int, error) {
+			if fail {
+				return 0, errLoad
+			}
+			return 1, nil
+		}
+
+		_, err := l.GetOrLoad(context.Background(), "aaa", loader)
+		require.ErrorIs(t, err, errLoad)
+
+		_, ok := l.Get("aaa")
+		require.False(t, ok)
+
+		fail = false
+		val, err := l.GetOrLoad(context.Background(), "aaa", loader)
+		require.NoError(t, err)
+		require.Equal(t, 1, val)
+	})
+
+	t.Run("errors are cached for error ttl", func(t *testing.T) {
+		l := NewLoadingCache(NewCache[string, int](10), WithErrorTTL[string, int](time.Second))
+		now := time.Now()
+		l.now = func() time.Time { return now }
+
+		var calls int32
+		loader := func(_ context.Context, _ string) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			return 0, errLoad
+		}
+
+		for i := 0; i < 3; i++ {
+			_, err := l.GetOrLoad(context.Background(), "aaa", loader)
+			require.ErrorIs(t, err, errLoad)
+		}
+		require.Equal(t, int32(1), calls)
+
+		now = now.Add(time.Second)
+
+		_, err := l.GetOrLoad(context.Background(), "aaa", loader)
+		require.ErrorIs(t, err, errLoad)
+		require.Equal(t, int32(2), calls)
+	})
+}
+
+func TestLoadingCacheCancel(t *testing.T) {
+	t.Run("cancelled caller", func(t *testing.T) {
+		l := NewLoadingCache(NewCache[string, int](10))
+
+		loaderCancelled := make(chan struct{})
+		loader := func(ctx context.Context, _ string) (int, error) {
+			<-ctx.Done()
+			close(loaderCancelled)
+			return 0, ctx.Err()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := l.GetOrLoad(ctx, "aaa", loader)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// the only waiter left, so the load is cancelled too
+		select {
+		case <-loaderCancelled:
+		case <-time.After(time.Second):
+			require.Fail(t, "loader context was not cancelled")
+		}
+	})
+
+	t.Run("load continues while someone waits", func(t *testing.T) {
+		l := NewLoadingCache(NewCache[string, int](10))
+
+		release := make(chan struct{})
+		loader := func(ctx context.Context, _ string) (int, error) {
+			select {
+			case <-release:
+				return 1, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+
+		result := make(chan error)
+		go func() {
+			_, err := l.GetOrLoad(context.Background(), "aaa", loader)
+			result <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			_, ok := l.calls["aaa"]
+			return ok
+		}, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := l.GetOrLoad(ctx, "aaa", loader)
+		require.ErrorIs(t, err, context.Canceled)
+
+		close(release)
+		require.NoError(t, <-result)
+
+		val, ok := l.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+	})
+}
@@ -0,0 +1,155 @@
+package lru
+
+// Policy selects which item is dropped when the cache is full.
+type Policy int
+
+const (
+	// PolicyLRU evicts the least recently used item.
+	PolicyLRU Policy = iota
+	// PolicyLFU evicts the least frequently used item, the least recently used one among equals.
+	PolicyLFU
+	// Policy2Q keeps items seen once in a FIFO queue, so a single scan can't flush frequently used items.
+	Policy2Q
+	// PolicyARC balances recency and frequency adaptively using the history of evicted keys.
+	PolicyARC
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "LRU"
+	case PolicyLFU:
+		return "LFU"
+	case Policy2Q:
+		return "2Q"
+	case PolicyARC:
+		return "ARC"
+	default:
+		return "unknown"
+	}
+}
+
+// WithPolicy sets the eviction policy. PolicyLRU is used by default.
+func WithPolicy[K comparable, V any](p Policy) Option[K, V] {
+	return func(c *lruCache[K, V]) {
+		c.policyKind = p
+	}
+}
+
+// policy keeps cache items ordered by their value for the cache.
+// The cache calls admit with a new key before making room for it, then add with the item itself.
+type policy[K comparable, V any] interface {
+	admit(key K)
+	add(ci *cacheItem[K, V])
+	touch(ci *cacheItem[K, V])
+	remove(ci *cacheItem[K, V])
+	// evict unlinks and returns the item to drop, nil when the policy is empty.
+	evict() *cacheItem[K, V]
+	clear()
+	// each visits items from the most to the least valuable one.
+	each(fn func(ci *cacheItem[K, V]))
+}
+
+func newPolicy[K comparable, V any](p Policy, capacity int) policy[K, V] {
+	switch p {
+	case PolicyLFU:
+		return newLFUPolicy[K, V]()
+	case Policy2Q:
+		return newTwoQueuePolicy[K, V](capacity)
+	case PolicyARC:
+		return newARCPolicy[K, V](capacity)
+	case PolicyLRU:
+	}
+
+	return newLRUPolicy[K, V]()
+}
+
+type lruPolicy[K comparable, V any] struct {
+	queue List[*cacheItem[K, V]]
+}
+
+func newLRUPolicy[K comparable, V any]() *lruPolicy[K, V] {
+	return &lruPolicy[K, V]{
+		queue: NewList[*cacheItem[K, V]](),
+	}
+}
+
+func (p *lruPolicy[K, V]) admit(K) {}
+
+func (p *lruPolicy[K, V]) add(ci *cacheItem[K, V]) {
+	ci.node = p.queue.PushFront(ci)
+}
+
+func (p *lruPolicy[K, V]) touch(ci *cacheItem[K, V]) {
+	p.queue.MoveToFront(ci.node)
+}
+
+func (p *lruPolicy[K, V]) remove(ci *cacheItem[K, V]) {
+	p.queue.Remove(ci.node)
+}
+
+func (p *lruPolicy[K, V]) evict() *cacheItem[K, V] {
+	return popBack(p.queue)
+}
+
+func (p *lruPolicy[K, V]) clear() {
+	p.queue = NewList[*cacheItem[K, V]]()
+}
+
+func (p *lruPolicy[K, V]) each(fn func(ci *cacheItem[K, V])) {
+	eachItem(p.queue, fn)
+}
+
+// ghostList remembers keys of recently evicted items, the most recent at the front.
+type ghostList[K comparable] struct {
+	keys  List[K]
+	index map[K]*ListItem[K]
+}
+
+func newGhostList[K comparable]() *ghostList[K] {
+	return &ghostList[K]{
+		keys:  NewList[K](),
+		index: make(map[K]*ListItem[K]),
+	}
+}
+
+func (g *ghostList[K]) Len() int {
+	return g.keys.Len()
+}
+
+func (g *ghostList[K]) push(key K) {
+	g.index[key] = g.keys.PushFront(key)
+}
+
+func (g *ghostList[K]) remove(key K) bool {
+	item, ok := g.index[key]
+	if !ok {
+		return false
+	}
+
+	g.keys.Remove(item)
+	delete(g.index, key)
+	return true
+}
+
+func (g *ghostList[K]) removeOldest() {
+	if back := g.keys.Back(); back != nil {
+		g.remove(back.Value)
+	}
+}
+
+func popBack[K comparable, V any](l List[*cacheItem[K, V]]) *cacheItem[K, V] {
+	back := l.Back()
+	if back == nil {
+		return nil
+	}
+
+	l.Remove(back)
+	return back.Value
+}
+
+func eachItem[K comparable, V any](l List[*cacheItem[K, V]], fn func(ci *cacheItem[K, V])) {
+	for item := l.Front(); item != nil; item = item.Next {
+		fn(item.Value)
+	}
+}
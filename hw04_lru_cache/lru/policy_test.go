@@ -0,0 +1,154 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUPolicy(t *testing.T) {
+	c := NewCache(3, WithPolicy[string, int](PolicyLRU))
+
+	c.Set("key1", 1)
+	c.Set("key2", 2)
+	c.Set("key3", 3)
+
+	c.Get("key1")
+	c.Set("key2", 22)
+
+	// least recently used element should be removed
+	c.Set("key4", 4)
+
+	_, ok := c.Get("key3")
+	require.False(t, ok)
+
+	require.Equal(t, []string{"key4", "key2", "key1"}, c.Keys())
+}
+
+func TestLFUPolicy(t *testing.T) {
+	c := NewCache(3, WithPolicy[string, int](PolicyLFU))
+
+	c.Set("key1", 1)
+	c.Set("key2", 2)
+	c.Set("key3", 3)
+
+	for i := 0; i < 3; i++ {
+		c.Get("key1")
+	}
+	c.Get("key2")
+	c.Get("key3")
+	c.Get("key3")
+
+	// key2 is used less often than others
+	c.Set("key4", 4)
+	_, ok := c.Get("key2")
+	require.False(t, ok)
+
+	// key4 is the only one used once
+	c.Set("key5", 5)
+	_, ok = c.Get("key4")
+	require.False(t, ok)
+
+	for _, key := range []string{"key1", "key3", "key5"} {
+		_, ok = c.Get(key)
+		require.True(t, ok, key)
+	}
+}
+
+func TestScanResistance(t *testing.T) {
+	const capacity = 8
+
+	hot := []string{"hot1", "hot2", "hot3", "hot4"}
+
+	// warmUp makes hot keys frequently used in the policy sense:
+	// 2Q has to see them again after they left its FIFO queue, others just need a repeated access.
+	warmUp := func(c Cache[string, int]) {
+		for _, key := range hot {
+			c.Set(key, 1)
+		}
+		for i := 0; i < capacity; i++ {
+			c.Set("warm"+strconv.Itoa(i), 1)
+		}
+		for _, key := range hot {
+			if _, ok := c.Get(key); !ok {
+				c.Set(key, 1)
+			}
+			c.Get(key)
+		}
+	}
+
+	scan := func(c Cache[string, int]) {
+		for i := 0; i < 100; i++ {
+			c.Set("scan"+strconv.Itoa(i), i)
+		}
+	}
+
+	hotHits := func(c Cache[string, int]) int {
+		hits := 0
+		for _, key := range hot {
+			if _, ok := c.Get(key); ok {
+				hits++
+			}
+		}
+		return hits
+	}
+
+	for _, p := range []Policy{Policy2Q, PolicyARC, PolicyLFU} {
+		t.Run(p.String(), func(t *testing.T) {
+			c := NewCache(capacity, WithPolicy[string, int](p))
+			warmUp(c)
+			scan(c)
+			require.Equal(t, len(hot), hotHits(c))
+		})
+	}
+
+	t.Run("LRU loses hot keys", func(t *testing.T) {
+		c := NewCache(capacity, WithPolicy[string, int](PolicyLRU))
+		warmUp(c)
+		scan(c)
+		require.Equal(t, 0, hotHits(c))
+	})
+}
+
+func TestTwoQueuePolicy(t *testing.T) {
+	c := NewCache(4, WithPolicy[string, int](Policy2Q))
+	p := c.(*lruCache[string, int]).policy.(*twoQueuePolicy[string, int])
+
+	for i := 1; i <= 5; i++ {
+		c.Set("key"+strconv.Itoa(i), i)
+	}
+
+	// key1 left the FIFO queue and is remembered as a ghost
+	_, ok := c.Get("key1")
+	require.False(t, ok)
+	require.Contains(t, p.out.index, "key1")
+
+	c.Set("key1", 1)
+	require.Equal(t, 1, p.main.Len())
+	require.Equal(t, "key1", p.main.Front().Value.key)
+	require.NotContains(t, p.out.index, "key1")
+}
+
+func TestARCPolicy(t *testing.T) {
+	c := NewCache(2, WithPolicy[string, int](PolicyARC))
+	p := c.(*lruCache[string, int]).policy.(*arcPolicy[string, int])
+
+	c.Set("key1", 1)
+	c.Set("key2", 2)
+	c.Get("key2")    // key2 moves to t2
+	c.Set("key3", 3) // key1 goes to b1
+	require.Contains(t, p.b1.index, "key1")
+	require.Equal(t, 0, p.p)
+
+	// a hit in b1 makes more room for recently used items
+	c.Set("key1", 1)
+	require.Equal(t, 1, p.p)
+	require.Equal(t, segmentFrequent, c.(*lruCache[string, int]).items["key1"].segment)
+	require.Contains(t, p.b2.index, "key2")
+
+	// repeated access moves an item from t1 to t2
+	c.Get("key3")
+	require.Equal(t, 0, p.t1.Len())
+	require.Equal(t, 2, p.t2.Len())
+}
@@ -0,0 +1,214 @@
+package lru
+
+import (
+	"fmt"
+	"hash/maphash"
+	"io"
+	"math"
+	"reflect"
+	"runtime"
+	"time"
+)
+
+// Hasher maps a key to the shard it belongs to.
+type Hasher[K comparable] func(key K) uint64
+
+type shardedCache[K comparable, V any] struct {
+	shards []*lruCache[K, V]
+	hash   Hasher[K]
+	codec  Codec
+}
+
+// NewShardedCache splits capacity between independent LRU shards, each guarded by its own lock.
+// Every shard gets capacity/shards entries, the remainder goes to the first shards. Max cost is split the same way.
+// Non-positive shards means runtime.GOMAXPROCS(0), there are never more shards than capacity or max cost,
+// so every shard can hold an item. Nil hash means a seeded hash of the key, see defaultHasher.
+func NewShardedCache[K comparable, V any](capacity, shards int, hash Hasher[K], opts ...Option[K, V]) Cache[K, V] {
+	cfg := &lruCache[K, V]{codec: GobCodec}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	if capacity > 0 {
+		shards = min(shards, capacity)
+	}
+	if cfg.maxCost > 0 {
+		shards = int(min(int64(shards), cfg.maxCost))
+	}
+
+	if hash == nil {
+		hash = defaultHasher[K]()
+	}
+
+	c := &shardedCache[K, V]{
+		shards: make([]*lruCache[K, V], shards),
+		hash:   hash,
+		codec:  cfg.codec,
+	}
+
+	for i := range c.shards {
+		shardCapacity := split(int64(capacity), shards, i)
+		shardOpts := opts
+		if cfg.maxCost > 0 {
+			shardOpts = append(opts[:len(opts):len(opts)], WithMaxCost[K, V](split(cfg.maxCost, shards, i)))
+		}
+		c.shards[i] = newCache(int(shardCapacity), shardOpts...)
+	}
+
+	return c
+}
+
+func (c *shardedCache[K, V]) shard(key K) *lruCache[K, V] {
+	return c.shards[c.hash(key)%uint64(len(c.shards))]
+}
+
+func (c *shardedCache[K, V]) Set(key K, value V) bool {
+	return c.shard(key).Set(key, value)
+}
+
+func (c *shardedCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	return c.shard(key).SetWithTTL(key, value, ttl)
+}
+
+func (c *shardedCache[K, V]) SetWithCost(key K, value V, cost int64) (bool, error) {
+	return c.shard(key).SetWithCost(key, value, cost)
+}
+
+func (c *shardedCache[K, V]) SetSized(key K, value V, ttl time.Duration) (bool, error) {
+	return c.shard(key).SetSized(key, value, ttl)
+}
+
+func (c *shardedCache[K, V]) Get(key K) (V, bool) {
+	return c.shard(key).Get(key)
+}
+
+func (c *shardedCache[K, V]) Peek(key K) (V, bool) {
+	return c.shard(key).Peek(key)
+}
+
+func (c *shardedCache[K, V]) Delete(key K) bool {
+	return c.shard(key).Delete(key)
+}
+
+// Keys returns keys of every shard in their order, shard after shard.
+func (c *shardedCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.Len())
+	for _, s := range c.shards {
+		keys = append(keys, s.Keys()...)
+	}
+	return keys
+}
+
+func (c *shardedCache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Len()
+	}
+	return n
+}
+
+func (c *shardedCache[K, V]) Clear() {
+	for _, s := range c.shards {
+		s.Clear()
+	}
+}
+
+// Stats sums the statistics of all shards.
+func (c *shardedCache[K, V]) Stats() Stats {
+	var stats Stats
+	for _, s := range c.shards {
+		st := s.Stats()
+		stats.Hits += st.Hits
+		stats.Misses += st.Misses
+		stats.Evictions += st.Evictions
+		stats.Expirations += st.Expirations
+		stats.Len += st.Len
+		stats.Capacity += st.Capacity
+		stats.Cost += st.Cost
+		stats.MaxCost += st.MaxCost
+	}
+	return stats
+}
+
+// Snapshot writes the items of every shard in their recency order, shard after shard.
+func (c *shardedCache[K, V]) Snapshot(w io.Writer) error {
+	var entries []snapshotEntry[K, V]
+	for _, s := range c.shards {
+		entries = append(entries, s.entries()...)
+	}
+	return writeSnapshot(c.codec.NewEncoder(w), entries)
+}
+
+// Restore puts every item into the shard of its key, items of one shard keep their order.
+func (c *shardedCache[K, V]) Restore(r io.Reader) error {
+	return readSnapshot(c.codec.NewDecoder(r), func(e snapshotEntry[K, V]) {
+		c.shard(e.Key).restore(e)
+	})
+}
+
+func (c *shardedCache[K, V]) Close() {
+	for _, s := range c.shards {
+		s.Close()
+	}
+}
+
+// split returns the share of total for the i-th of n parts.
+func split(total int64, n, i int) int64 {
+	share := total / int64(n)
+	if int64(i) < total%int64(n) {
+		share++
+	}
+	return share
+}
+
+// defaultHasher hashes keys of string, integer and float kinds, including named types like type ID int,
+// without allocations. The kind is looked up once, so the hasher doesn't need a type switch for every key.
+// Keys of other types are hashed by their %#v representation, which is slow and may put equal keys
+// into different shards, e.g. structs holding 0.0 and -0.0, so such keys need a Hasher.
+func defaultHasher[K comparable]() Hasher[K] {
+	seed := maphash.MakeSeed()
+
+	switch reflect.TypeFor[K]().Kind() {
+	case reflect.String:
+		return func(key K) uint64 {
+			return maphash.String(seed, reflect.ValueOf(key).String())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(key K) uint64 {
+			return mix(uint64(reflect.ValueOf(key).Int()))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(key K) uint64 {
+			return mix(reflect.ValueOf(key).Uint())
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(key K) uint64 {
+			return mix(floatBits(reflect.ValueOf(key).Float()))
+		}
+	default:
+		return func(key K) uint64 {
+			return maphash.String(seed, fmt.Sprintf("%#v", key))
+		}
+	}
+}
+
+// floatBits returns the bits of f, 0.0 and -0.0 are the same key and get the same bits.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+// mix is the splitmix64 finalizer, it spreads sequential integers over all shards.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
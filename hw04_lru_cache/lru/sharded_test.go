@@ -0,0 +1,192 @@
+package lru
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedCache(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		c := NewShardedCache[string, int](10, 4, nil)
+
+		wasInCache := c.Set("aaa", 100)
+		require.False(t, wasInCache)
+
+		wasInCache = c.Set("aaa", 200)
+		require.True(t, wasInCache)
+
+		val, ok := c.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 200, val)
+
+		require.True(t, c.Delete("aaa"))
+
+		_, ok = c.Get("aaa")
+		require.False(t, ok)
+	})
+
+	t.Run("peek, keys and len", func(t *testing.T) {
+		c := NewShardedCache[int, int](100, 4, nil)
+		for i := 0; i < 10; i++ {
+			c.Set(i, i)
+		}
+
+		val, ok := c.Peek(5)
+		require.True(t, ok)
+		require.Equal(t, 5, val)
+		require.Equal(t, uint64(0), c.Stats().Hits)
+
+		require.Equal(t, 10, c.Len())
+		require.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, c.Keys())
+	})
+
+	t.Run("per shard capacity", func(t *testing.T) {
+		c := NewShardedCache[int, int](10, 4, nil)
+
+		capacities := make([]int, 0, 4)
+		for _, s := range c.(*shardedCache[int, int]).shards {
+			capacities = append(capacities, s.Stats().Capacity)
+		}
+		require.Equal(t, []int{3, 3, 2, 2}, capacities)
+		require.Equal(t, 10, c.Stats().Capacity)
+	})
+
+	t.Run("purge logic inside shard", func(t *testing.T) {
+		// all keys land in the same shard
+		c := NewShardedCache[int, int](6, 2, func(int) uint64 { return 0 })
+
+		for i := 0; i < 4; i++ {
+			c.Set(i, i)
+		}
+
+		_, ok := c.Get(0)
+		require.False(t, ok)
+
+		stats := c.Stats()
+		require.Equal(t, 3, stats.Len)
+		require.Equal(t, uint64(1), stats.Evictions)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		c := NewShardedCache[int, int](100, 8, nil)
+
+		for i := 0; i < 50; i++ {
+			c.Set(i, i)
+		}
+		require.Equal(t, 50, c.Stats().Len)
+
+		c.Clear()
+		require.Equal(t, 0, c.Stats().Len)
+	})
+
+	t.Run("per shard max cost", func(t *testing.T) {
+		c := NewShardedCache(0, 4, nil, WithMaxCost[int, int](10))
+
+		maxCosts := make([]int64, 0, 4)
+		for _, s := range c.(*shardedCache[int, int]).shards {
+			maxCosts = append(maxCosts, s.Stats().MaxCost)
+		}
+		require.Equal(t, []int64{3, 3, 2, 2}, maxCosts)
+		require.Equal(t, int64(10), c.Stats().MaxCost)
+
+		_, err := c.SetWithCost(1, 1, 4)
+		require.ErrorIs(t, err, ErrItemTooLarge)
+	})
+
+	t.Run("default shard count", func(t *testing.T) {
+		c := NewShardedCache[string, int](100, 0, nil)
+		require.Len(t, c.(*shardedCache[string, int]).shards, runtime.GOMAXPROCS(0))
+	})
+
+	t.Run("no more shards than capacity", func(t *testing.T) {
+		c := NewShardedCache[int, int](3, 8, nil)
+		require.Len(t, c.(*shardedCache[int, int]).shards, 3)
+
+		for i := 0; i < 3; i++ {
+			c.Set(i, i)
+		}
+		require.Equal(t, 3, c.Stats().Capacity)
+
+		c = NewShardedCache(0, 8, nil, WithMaxCost[int, int](2))
+		require.Len(t, c.(*shardedCache[int, int]).shards, 2)
+	})
+
+	t.Run("named key types", func(t *testing.T) {
+		type userID int
+		type name string
+		type weight float32
+
+		ids := defaultHasher[userID]()
+		require.Equal(t, defaultHasher[int]()(0), ids(0))
+		require.NotEqual(t, ids(1), ids(2))
+		require.Zero(t, testing.AllocsPerRun(100, func() { ids(12345) }))
+
+		names := defaultHasher[name]()
+		require.Equal(t, names("alice"), names("alice"))
+		require.NotEqual(t, names("alice"), names("bob"))
+		require.Zero(t, testing.AllocsPerRun(100, func() { names("alice") }))
+
+		weights := defaultHasher[weight]()
+		require.Equal(t, weights(0), weights(weight(math.Copysign(0, -1))))
+		require.Zero(t, testing.AllocsPerRun(100, func() { weights(1.5) }))
+	})
+
+	t.Run("float keys", func(t *testing.T) {
+		hash := defaultHasher[float64]()
+		negZero := math.Copysign(0, -1)
+		require.Equal(t, hash(0), hash(negZero))
+		require.Equal(t, hash(1.5), hash(1.5))
+		require.NotEqual(t, hash(1.5), hash(2.5))
+		require.Zero(t, testing.AllocsPerRun(100, func() { hash(1.5) }))
+
+		c := NewShardedCache[float64, int](16, 16, nil)
+		c.Set(0, 1)
+		val, ok := c.Get(negZero)
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+	})
+}
+
+func BenchmarkCacheParallel(b *testing.B) {
+	const capacity = 10_000
+
+	caches := []struct {
+		name string
+		new  func() Cache[int, int]
+	}{
+		{"single", func() Cache[int, int] { return NewCache[int, int](capacity) }},
+		{"sharded-16", func() Cache[int, int] { return NewShardedCache[int, int](capacity, 16, nil) }},
+		{"sharded-64", func() Cache[int, int] { return NewShardedCache[int, int](capacity, 64, nil) }},
+	}
+
+	for _, procs := range []int{1, 2, 4, 8} {
+		for _, cc := range caches {
+			b.Run(fmt.Sprintf("procs=%d/%s", procs, cc.name), func(b *testing.B) {
+				defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+				c := cc.new()
+				for i := 0; i < capacity; i++ {
+					c.Set(i, i)
+				}
+
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					r := rand.New(rand.NewSource(rand.Int63()))
+					for pb.Next() {
+						key := r.Intn(2 * capacity)
+						if key%5 == 0 {
+							c.Set(key, key)
+						} else {
+							c.Get(key)
+						}
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package lru
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const snapshotVersion = 1
+
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+type Encoder interface {
+	Encode(v any) error
+}
+
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Codec serializes snapshots. Values stored as interfaces must be registered with gob.Register for GobCodec.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+type (
+	gobCodec  struct{}
+	jsonCodec struct{}
+)
+
+var (
+	GobCodec  Codec = gobCodec{}
+	JSONCodec Codec = jsonCodec{}
+)
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder {
+	return gob.NewEncoder(w)
+}
+
+func (gobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// WithCodec sets the codec of Snapshot and Restore. GobCodec is used by default.
+func WithCodec[K comparable, V any](codec Codec) Option[K, V] {
+	return func(c *lruCache[K, V]) {
+		c.codec = codec
+	}
+}
+
+type snapshotHeader struct {
+	Version int
+	Len     int
+}
+
+type snapshotEntry[K comparable, V any] struct {
+	Key       K
+	Value     V
+	Cost      int64
+	ExpiresAt time.Time
+}
+
+// Snapshot writes not expired items from the least to the most recently used one,
+// so Restore rebuilds the same order and keeps the most recent items if the cache is smaller.
+func (c *lruCache[K, V]) Snapshot(w io.Writer) error {
+	return writeSnapshot(c.codec.NewEncoder(w), c.entries())
+}
+
+// Restore adds items written by Snapshot on top of the current ones. Expired items are skipped.
+func (c *lruCache[K, V]) Restore(r io.Reader) error {
+	return readSnapshot(c.codec.NewDecoder(r), c.restore)
+}
+
+// entries returns not expired items from the least to the most valuable one.
+func (c *lruCache[K, V]) entries() []snapshotEntry[K, V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	entries := make([]snapshotEntry[K, V], 0, len(c.items))
+	c.policy.each(func(ci *cacheItem[K, V]) {
+		if !ci.expired(now) {
+			entries = append(entries, snapshotEntry[K, V]{
+				Key:       ci.key,
+				Value:     ci.value,
+				Cost:      ci.cost,
+				ExpiresAt: ci.expiresAt,
+			})
+		}
+	})
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries
+}
+
+func (c *lruCache[K, V]) restore(e snapshotEntry[K, V]) {
+	defer c.notifyEvicted()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt) {
+		return
+	}
+
+	// items which don't fit the max cost any more are dropped
+	_, _ = c.store(e.Key, e.Value, e.Cost, e.ExpiresAt, now)
+}
+
+func writeSnapshot[K comparable, V any](enc Encoder, entries []snapshotEntry[K, V]) error {
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion, Len: len(entries)}); err != nil {
+		return fmt.Errorf("encode snapshot header: %w", err)
+	}
+
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			return fmt.Errorf("encode snapshot entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func readSnapshot[K comparable, V any](dec Decoder, restore func(e snapshotEntry[K, V])) error {
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("decode snapshot header: %w", err)
+	}
+
+	if header.Version != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, header.Version)
+	}
+
+	for i := 0; i < header.Len; i++ {
+		var e snapshotEntry[K, V]
+		if err := dec.Decode(&e); err != nil {
+			return fmt.Errorf("decode snapshot entry: %w", err)
+		}
+		restore(e)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package lru
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func entryKeys[K comparable, V any](entries []snapshotEntry[K, V]) []K {
+	keys := make([]K, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestSnapshot(t *testing.T) {
+	fill := func(c Cache[string, int]) {
+		for i, key := range []string{"key1", "key2", "key3", "key4", "key5"} {
+			c.Set(key, i+1)
+		}
+		c.Get("key2")
+	}
+
+	t.Run("restore keeps recency order", func(t *testing.T) {
+		src := NewCache[string, int](5)
+		fill(src)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, src.Snapshot(buf))
+
+		dst := NewCache[string, int](5)
+		require.NoError(t, dst.Restore(buf))
+
+		order := []string{"key1", "key3", "key4", "key5", "key2"}
+		require.Equal(t, order, entryKeys(src.(*lruCache[string, int]).entries()))
+		require.Equal(t, order, entryKeys(dst.(*lruCache[string, int]).entries()))
+
+		val, ok := dst.Get("key3")
+		require.True(t, ok)
+		require.Equal(t, 3, val)
+	})
+
+	t.Run("restore respects capacity", func(t *testing.T) {
+		src := NewCache[string, int](5)
+		fill(src)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, src.Snapshot(buf))
+
+		dst := NewCache[string, int](3)
+		require.NoError(t, dst.Restore(buf))
+
+		require.Equal(t, []string{"key4", "key5", "key2"}, entryKeys(dst.(*lruCache[string, int]).entries()))
+	})
+
+	t.Run("ttl and cost are kept", func(t *testing.T) {
+		now := time.Now()
+
+		src := NewCache(5, WithMaxCost[string, int](10))
+		withClock(src, &now)
+		src.SetWithCost("key1", 1, 4)
+		src.SetWithTTL("key2", 2, time.Minute)
+		src.SetWithTTL("key3", 3, time.Hour)
+
+		now = now.Add(time.Minute)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, src.Snapshot(buf))
+
+		dst := NewCache(5, WithMaxCost[string, int](10))
+		withClock(dst, &now)
+		require.NoError(t, dst.Restore(buf))
+
+		_, ok := dst.Get("key2")
+		require.False(t, ok)
+		require.Equal(t, int64(5), dst.Stats().Cost)
+
+		now = now.Add(time.Hour)
+		_, ok = dst.Get("key3")
+		require.False(t, ok)
+
+		val, ok := dst.Get("key1")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+	})
+
+	t.Run("json codec", func(t *testing.T) {
+		src := NewCache(5, WithCodec[string, int](JSONCodec))
+		fill(src)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, src.Snapshot(buf))
+		require.Contains(t, buf.String(), `"Key":"key2"`)
+
+		dst := NewCache(5, WithCodec[string, int](JSONCodec))
+		require.NoError(t, dst.Restore(buf))
+		require.Equal(t, 5, dst.Stats().Len)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, GobCodec.NewEncoder(buf).Encode(snapshotHeader{Version: 100}))
+
+		err := NewCache[string, int](5).Restore(buf)
+		require.ErrorIs(t, err, ErrSnapshotVersion)
+	})
+
+	t.Run("truncated snapshot", func(t *testing.T) {
+		src := NewCache[string, int](5)
+		fill(src)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, src.Snapshot(buf))
+		buf.Truncate(buf.Len() / 2)
+
+		require.Error(t, NewCache[string, int](5).Restore(buf))
+	})
+
+	t.Run("sharded", func(t *testing.T) {
+		src := NewShardedCache[int, int](100, 4, nil)
+		for i := 0; i < 50; i++ {
+			src.Set(i, i*i)
+		}
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, src.Snapshot(buf))
+
+		dst := NewShardedCache[int, int](100, 8, nil)
+		require.NoError(t, dst.Restore(buf))
+		require.Equal(t, 50, dst.Stats().Len)
+
+		val, ok := dst.Get(7)
+		require.True(t, ok)
+		require.Equal(t, 49, val)
+	})
+}
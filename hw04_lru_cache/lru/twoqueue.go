@@ -0,0 +1,96 @@
+package lru
+
+type segment uint8
+
+const (
+	segmentRecent segment = iota
+	segmentFrequent
+)
+
+// twoQueuePolicy is the full 2Q algorithm (Johnson, Shasha, 1994).
+// New items go to the FIFO queue "in", items evicted from it are remembered in the ghost queue "out",
+// and only keys seen again while in "out" are promoted to the LRU queue "main".
+type twoQueuePolicy[K comparable, V any] struct {
+	capacity int
+	in       List[*cacheItem[K, V]]
+	main     List[*cacheItem[K, V]]
+	out      *ghostList[K]
+	promote  bool
+}
+
+func newTwoQueuePolicy[K comparable, V any](capacity int) *twoQueuePolicy[K, V] {
+	return &twoQueuePolicy[K, V]{
+		capacity: capacity,
+		in:       NewList[*cacheItem[K, V]](),
+		main:     NewList[*cacheItem[K, V]](),
+		out:      newGhostList[K](),
+	}
+}
+
+// size is the number of items the queue limits are derived from.
+// A cache bounded by cost has no fixed item count, so the current one is used.
+func (p *twoQueuePolicy[K, V]) size() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return p.in.Len() + p.main.Len()
+}
+
+func (p *twoQueuePolicy[K, V]) admit(key K) {
+	p.promote = p.out.remove(key)
+}
+
+func (p *twoQueuePolicy[K, V]) add(ci *cacheItem[K, V]) {
+	if p.promote {
+		ci.segment = segmentFrequent
+		ci.node = p.main.PushFront(ci)
+	} else {
+		ci.segment = segmentRecent
+		ci.node = p.in.PushFront(ci)
+	}
+	p.promote = false
+}
+
+func (p *twoQueuePolicy[K, V]) touch(ci *cacheItem[K, V]) {
+	if ci.segment == segmentFrequent {
+		p.main.MoveToFront(ci.node)
+	}
+}
+
+func (p *twoQueuePolicy[K, V]) remove(ci *cacheItem[K, V]) {
+	if ci.segment == segmentFrequent {
+		p.main.Remove(ci.node)
+	} else {
+		p.in.Remove(ci.node)
+	}
+}
+
+func (p *twoQueuePolicy[K, V]) evict() *cacheItem[K, V] {
+	inLimit := max(1, p.size()/4)
+	outLimit := max(1, p.size()/2)
+
+	if p.in.Len() <= inLimit && p.main.Len() > 0 {
+		return popBack(p.main)
+	}
+
+	ci := popBack(p.in)
+	if ci != nil {
+		p.out.push(ci.key)
+		for p.out.Len() > outLimit {
+			p.out.removeOldest()
+		}
+	}
+	return ci
+}
+
+func (p *twoQueuePolicy[K, V]) clear() {
+	p.in = NewList[*cacheItem[K, V]]()
+	p.main = NewList[*cacheItem[K, V]]()
+	p.out = newGhostList[K]()
+	p.promote = false
+}
+
+func (p *twoQueuePolicy[K, V]) each(fn func(ci *cacheItem[K, V])) {
+	eachItem(p.main, fn)
+	eachItem(p.in, fn)
+}
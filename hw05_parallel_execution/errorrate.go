@@ -0,0 +1,74 @@
+package hw05parallelexecution
+
+import "sync"
+
+// ErrorRate stops the run when more than Threshold of the last Window finished tasks failed.
+// It is checked only after Window tasks have finished, so early failures don't stop a long run.
+type ErrorRate struct {
+	Threshold float64 // from 0 to 1
+	Window    int
+}
+
+// errorRate tracks the results of the last finished tasks in a ring.
+type errorRate struct {
+	mu         sync.Mutex
+	threshold  float64
+	results    []bool // true for a failed task
+	next       int
+	finished   int
+	failed     int
+	exceeded   bool
+	onExceeded func()
+}
+
+// newErrorRate returns nil, which is never exceeded, if rate is nil or its window is empty.
+func newErrorRate(rate *ErrorRate, onExceeded func()) *errorRate {
+	if rate == nil || rate.Window <= 0 {
+		return nil
+	}
+
+	return &errorRate{
+		threshold:  rate.Threshold,
+		results:    make([]bool, rate.Window),
+		onExceeded: onExceeded,
+	}
+}
+
+func (r *errorRate) Add(failed bool) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.results[r.next] {
+		r.failed--
+	}
+	if failed {
+		r.failed++
+	}
+	r.results[r.next] = failed
+	r.next = (r.next + 1) % len(r.results)
+	r.finished++
+
+	if r.exceeded || r.finished < len(r.results) {
+		return
+	}
+
+	if float64(r.failed) > r.threshold*float64(len(r.results)) {
+		r.exceeded = true
+		r.onExceeded()
+	}
+}
+
+func (r *errorRate) Exceeded() bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.exceeded
+}
@@ -0,0 +1,283 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type errorsCounter struct {
+	mu         sync.RWMutex
+	m          int
+	count      int
+	onExceeded func()
+}
+
+func newErrorsCounter(m int, onExceeded func()) *errorsCounter {
+	c := &errorsCounter{
+		m:          m,
+		onExceeded: onExceeded,
+	}
+
+	if m == 0 {
+		onExceeded()
+	}
+
+	return c
+}
+
+func (c *errorsCounter) Increment() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count++
+
+	if c.m > 0 && c.count == c.m {
+		c.onExceeded()
+	}
+}
+
+func (c *errorsCounter) Exceeded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.m == 0 {
+		return true
+	}
+
+	if c.m < 0 {
+		return false
+	}
+
+	return c.count >= c.m
+}
+
+// source returns the next task. It must give up and return false when ctx is done.
+type source func(ctx context.Context) (ContextTask, bool)
+
+type job struct {
+	index  int
+	weight int
+	task   ContextTask
+}
+
+// executor runs tasks taken from a source in a fixed number of workers.
+type executor struct {
+	opts      Options
+	taskIndex func(dispatched int) int
+	record    func(index int, res TaskResult)
+	errors    *errorsCounter
+	errorRate *errorRate
+	stop      <-chan struct{}
+	limiter   *rateLimiter
+	tracker   tracker
+	panicOnce sync.Once
+	panicErr  *PanicError
+	consumed  int
+	exhausted bool
+}
+
+// newExecutor creates an executor calling record with the result of every started task.
+// taskIndex maps the position of a task in the dispatch order to the index passed to Weight, Observer and record,
+// nil keeps the position. record is called concurrently from the workers.
+func newExecutor(
+	opts Options, taskIndex func(dispatched int) int, record func(index int, res TaskResult),
+) *executor {
+	if taskIndex == nil {
+		taskIndex = func(dispatched int) int { return dispatched }
+	}
+
+	return &executor{
+		opts:      opts,
+		taskIndex: taskIndex,
+		record:    record,
+		limiter:   newRateLimiter(opts.RateLimit, opts.Burst),
+		tracker:   tracker{total: -1},
+	}
+}
+
+// run takes a task from next only when a worker is free to start it and the rate limit allows it,
+// and stops taking them when ctx is done or the errors limit or the error rate is exceeded.
+func (e *executor) run(ctx context.Context, next source) {
+	dispatchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	e.errors = newErrorsCounter(e.opts.MaxErrors, stop)
+	e.errorRate = newErrorRate(e.opts.ErrorRate, stop)
+	e.stop = dispatchCtx.Done()
+	e.tracker.start = time.Now()
+
+	if e.opts.Observer != nil {
+		defer func() {
+			e.opts.Observer.Progress(e.tracker.progress())
+		}()
+
+		if e.opts.ProgressInterval > 0 {
+			reported := make(chan struct{})
+			defer func() { <-reported }()
+
+			finished := make(chan struct{})
+			defer close(finished)
+
+			go func() {
+				defer close(reported)
+				e.tracker.report(e.opts.Observer, e.opts.ProgressInterval, finished)
+			}()
+		}
+	}
+
+	workers := max(e.opts.Workers, 1)
+	slots := make(chan struct{}, workers)
+	jobs := make(chan job)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				e.runJob(ctx, j)
+				for i := 0; i < j.weight; i++ {
+					<-slots
+				}
+			}
+		}()
+	}
+
+	e.dispatch(dispatchCtx, next, slots, jobs)
+	close(jobs)
+
+	wg.Wait()
+
+	if e.opts.Repanic && e.panicErr != nil {
+		panic(e.panicErr)
+	}
+}
+
+func (e *executor) dispatch(ctx context.Context, next source, slots chan<- struct{}, jobs chan<- job) {
+	workers := cap(slots)
+	for ctx.Err() == nil {
+		if !acquire(ctx, slots, 1) || !e.limiter.wait(ctx) {
+			return
+		}
+
+		task, ok := next(ctx)
+		if !ok {
+			e.exhausted = ctx.Err() == nil
+			return
+		}
+
+		j := job{index: e.taskIndex(e.consumed), weight: 1, task: task}
+		e.consumed++
+
+		if e.opts.Weight != nil {
+			j.weight = min(max(e.opts.Weight(j.index), 1), workers)
+			if !acquire(ctx, slots, j.weight-1) {
+				return
+			}
+		}
+
+		jobs <- j
+	}
+}
+
+// acquire takes n slots, it returns false if ctx is done before.
+func acquire(ctx context.Context, slots chan<- struct{}, n int) bool {
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			return false
+		case slots <- struct{}{}:
+		}
+	}
+	return ctx.Err() == nil
+}
+
+func (e *executor) runJob(ctx context.Context, j job) {
+	if e.limitExceeded() != nil || ctx.Err() != nil {
+		return
+	}
+
+	e.tracker.started.Add(1)
+	if e.opts.Observer != nil {
+		e.opts.Observer.TaskStarted(j.index)
+	}
+
+	start := time.Now()
+	retries, err := e.opts.Retry.do(ctx, e.stop, func() error {
+		return runTask(ctx, j.task, e.opts.TaskTimeout)
+	})
+	duration := time.Since(start)
+
+	res := TaskResult{Status: TaskSucceeded, Retries: retries}
+	if err != nil {
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			e.panicOnce.Do(func() { e.panicErr = panicErr })
+		}
+
+		res = TaskResult{Status: TaskFailed, Err: err, Retries: retries}
+		e.tracker.failed.Add(1)
+		e.errors.Increment()
+	}
+	e.errorRate.Add(err != nil)
+	e.tracker.finished.Add(1)
+	e.record(j.index, res)
+
+	if e.opts.Observer != nil {
+		e.opts.Observer.TaskFinished(j.index, res, duration)
+		if err != nil {
+			e.opts.Observer.TaskFailed(j.index, err)
+		}
+	}
+}
+
+// limitExceeded returns the sentinel error of the exceeded limit or nil.
+func (e *executor) limitExceeded() error {
+	if e.errors.Exceeded() {
+		return ErrErrorsLimitExceeded
+	}
+	if e.errorRate.Exceeded() {
+		return ErrErrorRateExceeded
+	}
+	return nil
+}
+
+// err returns the error of the finished run. taskErrors is called only if a limit was exceeded.
+func (e *executor) err(ctx context.Context, taskErrors func() []*TaskError) error {
+	if limit := e.limitExceeded(); limit != nil {
+		return &RunError{Limit: limit, Errors: taskErrors()}
+	}
+
+	if !e.exhausted {
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+func runTask(ctx context.Context, task ContextTask, timeout time.Duration) error {
+	if timeout <= 0 {
+		return callTask(ctx, task)
+	}
+
+	taskCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- callTask(taskCtx, task)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-taskCtx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrTaskTimeout
+	}
+}
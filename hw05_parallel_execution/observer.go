@@ -0,0 +1,131 @@
+package hw05parallelexecution
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+// Observer watches a run. TaskStarted, TaskFinished and TaskFailed are called concurrently from the workers,
+// TaskFailed is called after TaskFinished of a failed task. Progress is called every Options.ProgressInterval
+// and once when the run ends.
+type Observer interface {
+	TaskStarted(index int)
+	TaskFinished(index int, res TaskResult, duration time.Duration)
+	TaskFailed(index int, err error)
+	Progress(p Progress)
+}
+
+// Progress is a snapshot of a run.
+type Progress struct {
+	Done      int // finished tasks including the failed ones
+	Failed    int
+	InFlight  int
+	Remaining int // tasks not started yet, -1 for RunStream and RunSeq
+	Elapsed   time.Duration
+	// Throughput is the number of finished tasks per second since the run start.
+	Throughput float64
+}
+
+// tracker counts tasks for Progress.
+type tracker struct {
+	total    int
+	started  atomic.Int64
+	finished atomic.Int64
+	failed   atomic.Int64
+	start    time.Time
+}
+
+func (t *tracker) progress() Progress {
+	// finished is loaded first so InFlight is never negative
+	finished := t.finished.Load()
+	started := t.started.Load()
+
+	p := Progress{
+		Done:      int(finished),
+		Failed:    int(t.failed.Load()),
+		InFlight:  int(started - finished),
+		Remaining: -1,
+		Elapsed:   time.Since(t.start),
+	}
+
+	if t.total >= 0 {
+		p.Remaining = t.total - int(started)
+	}
+
+	if p.Elapsed > 0 {
+		p.Throughput = float64(finished) / p.Elapsed.Seconds()
+	}
+
+	return p
+}
+
+// report calls observer.Progress every interval until stop is closed.
+func (t *tracker) report(observer Observer, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			observer.Progress(t.progress())
+		}
+	}
+}
+
+// ExpvarObserver publishes task counters and the last progress of the runs it watches in an expvar.Map.
+type ExpvarObserver struct {
+	vars *expvar.Map
+
+	started, finished, failed expvar.Int
+	done, inFlight, remaining expvar.Int
+	elapsed, throughput       expvar.Float
+}
+
+// NewExpvarObserver publishes the variables under name. A map already published under name is reused,
+// so the observers of the same job may be created once per run. It panics if name is used by another variable type.
+func NewExpvarObserver(name string) *ExpvarObserver {
+	vars, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		vars = expvar.NewMap(name)
+	}
+
+	o := &ExpvarObserver{vars: vars}
+	vars.Set("tasks_started", &o.started)
+	vars.Set("tasks_finished", &o.finished)
+	vars.Set("tasks_failed", &o.failed)
+	vars.Set("progress_done", &o.done)
+	vars.Set("progress_in_flight", &o.inFlight)
+	vars.Set("progress_remaining", &o.remaining)
+	vars.Set("progress_elapsed_seconds", &o.elapsed)
+	vars.Set("progress_throughput", &o.throughput)
+
+	return o
+}
+
+// Vars returns the published map.
+func (o *ExpvarObserver) Vars() *expvar.Map {
+	return o.vars
+}
+
+func (o *ExpvarObserver) TaskStarted(int) {
+	o.started.Add(1)
+}
+
+func (o *ExpvarObserver) TaskFinished(int, TaskResult, time.Duration) {
+	o.finished.Add(1)
+}
+
+func (o *ExpvarObserver) TaskFailed(int, error) {
+	o.failed.Add(1)
+}
+
+func (o *ExpvarObserver) Progress(p Progress) {
+	o.done.Set(int64(p.Done))
+	o.inFlight.Set(int64(p.InFlight))
+	o.remaining.Set(int64(p.Remaining))
+	o.elapsed.Set(p.Elapsed.Seconds())
+	o.throughput.Set(p.Throughput)
+}
@@ -0,0 +1,60 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"time"
+)
+
+// rateLimiter is a token bucket which is refilled with rate tokens per second up to burst tokens.
+// It is used by the single dispatching goroutine, so it has no locks.
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// newRateLimiter returns nil, which never waits, if rate is not positive.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	burst = max(burst, 1)
+
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// wait takes a token, waiting for it if the bucket is empty. It returns false if ctx is done before.
+func (l *rateLimiter) wait(ctx context.Context) bool {
+	if l == nil {
+		return ctx.Err() == nil
+	}
+
+	for {
+		now := l.now()
+		if !l.last.IsZero() {
+			l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			return true
+		}
+
+		timer := time.NewTimer(time.Duration((1 - l.tokens) / l.rate * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy restarts failed tasks. Only the error of the last attempt is counted against the errors limit.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of task runs including the first one.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, it is doubled for every next one up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter randomly shortens every delay by up to this fraction of it, from 0 to 1.
+	Jitter float64
+	// Retryable reports whether the task may be restarted after err. Nil means every error is retryable.
+	Retryable func(err error) bool
+}
+
+// do calls attempt until it succeeds, returns a not retryable error or runs out of attempts.
+// Waiting for the next attempt is interrupted when ctx is done or stop is closed.
+func (p *RetryPolicy) do(ctx context.Context, stop <-chan struct{}, attempt func() error) (int, error) {
+	retries := 0
+	for {
+		err := attempt()
+		if err == nil || !p.retryable(err, retries) {
+			return retries, err
+		}
+
+		timer := time.NewTimer(p.delay(retries))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return retries, err
+		case <-stop:
+			timer.Stop()
+			return retries, err
+		case <-timer.C:
+		}
+
+		retries++
+	}
+}
+
+func (p *RetryPolicy) retryable(err error, retries int) bool {
+	if p == nil || retries+1 >= p.MaxAttempts {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// delay returns the backoff before the retry following the given number of retries.
+// Without MaxBackoff it stops growing at the maximum duration.
+func (p *RetryPolicy) delay(retries int) time.Duration {
+	d := p.Backoff
+	for i := 0; i < retries && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		if d > math.MaxInt64/2 {
+			d = math.MaxInt64
+			break
+		}
+		d *= 2
+	}
+
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		d -= time.Duration(float64(d) * min(p.Jitter, 1) * rand.Float64()) //nolint:gosec // no need in secure random
+	}
+
+	return d
+}
@@ -1,81 +1,232 @@
 package hw05parallelexecution
 
 import (
+	"context"
 	"errors"
-	"sync"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 )
 
-var ErrErrorsLimitExceeded = errors.New("errors limit exceeded")
+var (
+	ErrErrorsLimitExceeded = errors.New("errors limit exceeded")
+	ErrErrorRateExceeded   = errors.New("error rate exceeded")
+	ErrTaskTimeout         = errors.New("task timeout exceeded")
+)
 
 type Task func() error
 
-type errorsCounter struct {
-	mu    sync.RWMutex
-	m     int
-	count int
+// ContextTask is a task which should stop when ctx is done.
+type ContextTask func(ctx context.Context) error
+
+type Options struct {
+	// Workers is the number of goroutines running tasks, at least one is started.
+	Workers int
+	// MaxErrors stops the run after this many failed tasks.
+	// Zero means no task is started, negative means errors are ignored.
+	MaxErrors int
+	// TaskTimeout fails a task which doesn't return in time. The task is not waited for after that,
+	// so it should watch its context to exit. Zero means no timeout.
+	TaskTimeout time.Duration
+	// Retry restarts failed tasks, nil means every task runs once.
+	Retry *RetryPolicy
+	// RateLimit is the maximum number of tasks started per second, zero means no limit.
+	// Up to Burst tasks may start at once after a pause, at least one.
+	RateLimit float64
+	Burst     int
+	// Weight returns the number of Workers slots the task with the given index takes while running,
+	// from one to Workers. Nil means every task takes one slot.
+	Weight func(index int) int
+	// ErrorRate is a stop condition which works together with MaxErrors,
+	// set MaxErrors to a negative value to use only the error rate.
+	ErrorRate *ErrorRate
+	// Priority makes tasks with a higher priority start first, tasks of the same priority
+	// start in their order. Weight and Observer still get the task index. RunStream and RunSeq ignore it.
+	Priority func(index int) int
+	// Observer is notified about every task and the run progress, nil means no notifications.
+	Observer Observer
+	// ProgressInterval is the period of Observer.Progress calls during the run,
+	// zero means it is called only when the run ends.
+	ProgressInterval time.Duration
+	// Repanic makes the run panic with the *PanicError of the first panicked task after all workers have stopped.
+	// By default panics are only returned as task errors.
+	Repanic bool
 }
 
-func (c *errorsCounter) Increment() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.count++
+// TaskError is an error returned by the task with the given index.
+type TaskError struct {
+	Index int
+	Err   error
 }
 
-func (c *errorsCounter) Exceeded() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %d: %v", e.Index, e.Err)
+}
 
-	if c.m == 0 {
-		return true
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// RunError is returned when the errors limit or the error rate is exceeded. It wraps Limit
+// and every task error, so both can be checked with errors.Is and errors.As.
+type RunError struct {
+	Limit  error        // ErrErrorsLimitExceeded or ErrErrorRateExceeded
+	Errors []*TaskError // ordered by task index
+}
+
+func (e *RunError) Error() string {
+	b := strings.Builder{}
+	b.WriteString(e.limit().Error())
+	for _, err := range e.Errors {
+		b.WriteString("\n")
+		b.WriteString(err.Error())
 	}
+	return b.String()
+}
 
-	if c.m < 0 {
-		return false
+func (e *RunError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors)+1)
+	errs = append(errs, e.limit())
+	for _, err := range e.Errors {
+		errs = append(errs, err)
 	}
+	return errs
+}
 
-	return c.count >= c.m
+func (e *RunError) limit() error {
+	if e.Limit == nil {
+		return ErrErrorsLimitExceeded
+	}
+	return e.Limit
 }
 
-// Run starts tasks in n goroutines and stops its work when receiving m errors from tasks.
-func Run(tasks []Task, n, m int) error {
-	tasksCh := make(chan Task, len(tasks))
-	for i := range tasks {
-		tasksCh <- tasks[i]
+type TaskStatus int
+
+const (
+	TaskNotStarted TaskStatus = iota
+	TaskSucceeded
+	TaskFailed
+)
+
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskNotStarted:
+		return "not started"
+	case TaskSucceeded:
+		return "succeeded"
+	case TaskFailed:
+		return "failed"
+	default:
+		return "unknown"
 	}
-	close(tasksCh)
+}
 
-	errCounter := errorsCounter{m: m}
-	wg := sync.WaitGroup{}
+type TaskResult struct {
+	Status  TaskStatus
+	Err     error // error of the last attempt
+	Retries int
+}
 
-	for i := 0; i < n; i++ {
-		wg.Add(1)
+// Report holds the result of every task, Results[i] belongs to tasks[i].
+type Report struct {
+	Results []TaskResult
+}
 
-		go func() {
-			defer wg.Done()
+// Count returns the number of tasks with the given status.
+func (r Report) Count(status TaskStatus) int {
+	count := 0
+	for _, res := range r.Results {
+		if res.Status == status {
+			count++
+		}
+	}
+	return count
+}
 
-			for {
-				if errCounter.Exceeded() {
-					return
-				}
+// Retries returns the number of retries of all tasks.
+func (r Report) Retries() int {
+	retries := 0
+	for _, res := range r.Results {
+		retries += res.Retries
+	}
+	return retries
+}
 
-				task, ok := <-tasksCh
-				if !ok {
-					return
-				}
+func (r Report) errors() []*TaskError {
+	var errs []*TaskError
+	for i, res := range r.Results {
+		if res.Status == TaskFailed {
+			errs = append(errs, &TaskError{Index: i, Err: res.Err})
+		}
+	}
+	return errs
+}
 
-				err := task()
-				if err != nil {
-					errCounter.Increment()
-				}
-			}
-		}()
+// Run starts tasks in n goroutines and stops its work when receiving m errors from tasks.
+func Run(tasks []Task, n, m int) error {
+	ctxTasks := make([]ContextTask, 0, len(tasks))
+	for i := range tasks {
+		task := tasks[i]
+		ctxTasks = append(ctxTasks, func(context.Context) error {
+			return task()
+		})
 	}
 
-	wg.Wait()
+	return RunContext(context.Background(), ctxTasks, Options{Workers: n, MaxErrors: m})
+}
 
-	if errCounter.Exceeded() {
-		return ErrErrorsLimitExceeded
+// RunContext is Run which stops taking new tasks when ctx is done and passes ctx to the tasks.
+// It returns *RunError if the errors limit or the error rate was exceeded,
+// ctx.Err() if the context was done before all tasks were started and nil otherwise.
+func RunContext(ctx context.Context, tasks []ContextTask, opts Options) error {
+	_, err := RunWithReport(ctx, tasks, opts)
+	return err
+}
+
+// RunWithReport is RunContext which also reports what happened to every task.
+func RunWithReport(ctx context.Context, tasks []ContextTask, opts Options) (Report, error) {
+	report := Report{Results: make([]TaskResult, len(tasks))}
+
+	order := dispatchOrder(len(tasks), opts.Priority)
+	e := newExecutor(opts, func(dispatched int) int {
+		return order[dispatched]
+	}, func(index int, res TaskResult) {
+		report.Results[index] = res
+	})
+
+	e.tracker.total = len(tasks)
+
+	next := 0
+	e.run(ctx, func(context.Context) (ContextTask, bool) {
+		if next == len(tasks) {
+			return nil, false
+		}
+		next++
+		return tasks[order[next-1]], true
+	})
+	// the slice is exhausted by its last task even if ctx is done before the next one is asked for
+	e.exhausted = e.exhausted || e.consumed == len(tasks)
+
+	return report, e.err(ctx, report.errors)
+}
+
+// dispatchOrder returns task indexes in the order of their start.
+func dispatchOrder(n int, priority func(index int) int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+
+	if priority != nil {
+		priorities := make([]int, n)
+		for i := range priorities {
+			priorities[i] = priority(i)
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return priorities[order[i]] > priorities[order[j]]
+		})
 	}
 
-	return nil
+	return order
 }
@@ -1,9 +1,13 @@
 package hw05parallelexecution
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -121,3 +125,827 @@ func TestRun(t *testing.T) {
 		require.LessOrEqual(t, int64(elapsedTime), int64(sumTime/2), "tasks were run sequentially?")
 	})
 }
+
+func TestRunContext(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("tasks receive context", func(t *testing.T) {
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+		var withValue int32
+		tasks := make([]ContextTask, 0, 10)
+		for i := 0; i < 10; i++ {
+			tasks = append(tasks, func(ctx context.Context) error {
+				if ctx.Value(ctxKey{}) == "value" {
+					atomic.AddInt32(&withValue, 1)
+				}
+				return nil
+			})
+		}
+
+		err := RunContext(ctx, tasks, Options{Workers: 3, MaxErrors: 1})
+		require.NoError(t, err)
+		require.Equal(t, int32(10), withValue)
+	})
+
+	t.Run("cancel stops dispatching", func(t *testing.T) {
+		tasksCount := 50
+		tasks := make([]ContextTask, 0, tasksCount)
+
+		var runTasksCount int32
+		for i := 0; i < tasksCount; i++ {
+			tasks = append(tasks, func(ctx context.Context) error {
+				atomic.AddInt32(&runTasksCount, 1)
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		workersCount := 4
+		result := make(chan error)
+		go func() {
+			result <- RunContext(ctx, tasks, Options{Workers: workersCount, MaxErrors: -1})
+		}()
+
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&runTasksCount) == int32(workersCount)
+		}, time.Second, time.Millisecond)
+		cancel()
+
+		err := <-result
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, int32(workersCount), runTasksCount, "tasks were started after cancel")
+	})
+
+	t.Run("done context starts nothing", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var runTasksCount int32
+		tasks := []ContextTask{func(context.Context) error {
+			atomic.AddInt32(&runTasksCount, 1)
+			return nil
+		}}
+
+		err := RunContext(ctx, tasks, Options{Workers: 2, MaxErrors: 1})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, int32(0), runTasksCount)
+	})
+
+	t.Run("slow tasks fail by timeout", func(t *testing.T) {
+		tasksCount := 20
+		tasks := make([]ContextTask, 0, tasksCount)
+
+		var finishedCount int32
+		for i := 0; i < tasksCount; i++ {
+			slow := i%2 == 0
+			tasks = append(tasks, func(ctx context.Context) error {
+				if slow {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				atomic.AddInt32(&finishedCount, 1)
+				return nil
+			})
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:     5,
+			MaxErrors:   -1,
+			TaskTimeout: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(tasksCount/2), finishedCount)
+
+		err = RunContext(context.Background(), tasks, Options{
+			Workers:     5,
+			MaxErrors:   3,
+			TaskTimeout: 10 * time.Millisecond,
+		})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+	})
+
+	t.Run("stuck task doesn't block run", func(t *testing.T) {
+		release := make(chan struct{})
+		stuckDone := make(chan struct{})
+		defer func() {
+			close(release)
+			<-stuckDone
+		}()
+
+		tasks := []ContextTask{
+			func(context.Context) error {
+				defer close(stuckDone)
+				<-release // ignores its context
+				return nil
+			},
+			func(context.Context) error {
+				return nil
+			},
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:     2,
+			MaxErrors:   1,
+			TaskTimeout: 10 * time.Millisecond,
+		})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	taskErrs := map[int]error{
+		2: errors.New("error from task 2"),
+		5: errors.New("error from task 5"),
+		7: errors.New("error from task 7"),
+	}
+
+	tasks := make([]ContextTask, 0, 10)
+	for i := 0; i < 10; i++ {
+		err := taskErrs[i]
+		tasks = append(tasks, func(context.Context) error {
+			return err
+		})
+	}
+
+	t.Run("errors are collected", func(t *testing.T) {
+		err := RunContext(context.Background(), tasks, Options{Workers: 1, MaxErrors: 3})
+
+		require.Truef(t, errors.Is(err, ErrErrorsLimitExceeded), "actual err - %v", err)
+		for _, taskErr := range taskErrs {
+			require.ErrorIs(t, err, taskErr)
+		}
+
+		var runErr *RunError
+		require.ErrorAs(t, err, &runErr)
+		require.Len(t, runErr.Errors, 3)
+		for i, index := range []int{2, 5, 7} {
+			require.Equal(t, index, runErr.Errors[i].Index)
+			require.Equal(t, taskErrs[index], runErr.Errors[i].Err)
+		}
+
+		var taskErr *TaskError
+		require.ErrorAs(t, err, &taskErr)
+		require.Equal(t, 2, taskErr.Index)
+		require.Contains(t, err.Error(), "task 5: error from task 5")
+	})
+
+	t.Run("report", func(t *testing.T) {
+		report, err := RunWithReport(context.Background(), tasks, Options{Workers: 1, MaxErrors: 3})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+
+		require.Len(t, report.Results, len(tasks))
+		for i := 0; i <= 7; i++ {
+			if taskErrs[i] != nil {
+				require.Equal(t, TaskResult{Status: TaskFailed, Err: taskErrs[i]}, report.Results[i])
+			} else {
+				require.Equal(t, TaskResult{Status: TaskSucceeded}, report.Results[i])
+			}
+		}
+		require.Equal(t, TaskResult{Status: TaskNotStarted}, report.Results[8])
+		require.Equal(t, TaskResult{Status: TaskNotStarted}, report.Results[9])
+
+		require.Equal(t, 5, report.Count(TaskSucceeded))
+		require.Equal(t, 3, report.Count(TaskFailed))
+		require.Equal(t, 2, report.Count(TaskNotStarted))
+	})
+
+	t.Run("report without limit", func(t *testing.T) {
+		report, err := RunWithReport(context.Background(), tasks, Options{Workers: 4, MaxErrors: -1})
+		require.NoError(t, err)
+		require.Equal(t, 7, report.Count(TaskSucceeded))
+		require.Equal(t, 3, report.Count(TaskFailed))
+	})
+}
+
+func TestRunStream(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("producer is blocked while workers are busy", func(t *testing.T) {
+		workersCount := 2
+		tasks := make(chan ContextTask)
+		release := make(chan struct{})
+		var sent int32
+
+		go func() {
+			defer close(tasks)
+			for i := 0; i < 5; i++ {
+				tasks <- func(context.Context) error {
+					<-release
+					return nil
+				}
+				atomic.AddInt32(&sent, 1)
+			}
+		}()
+
+		done := make(chan struct{})
+		var (
+			report StreamReport
+			err    error
+		)
+		go func() {
+			defer close(done)
+			report, err = RunStream(context.Background(), tasks, Options{Workers: workersCount, MaxErrors: 1})
+		}()
+
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&sent) == int32(workersCount)
+		}, time.Second, time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(t, int32(workersCount), atomic.LoadInt32(&sent), "tasks were taken without free workers")
+
+		close(release)
+		<-done
+
+		require.NoError(t, err)
+		require.Equal(t, StreamReport{Consumed: 5, Succeeded: 5}, report)
+	})
+
+	t.Run("errors limit stops consuming", func(t *testing.T) {
+		taskErr := errors.New("task error")
+		tasks := make(chan ContextTask)
+		stop := make(chan struct{})
+		defer close(stop)
+
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				case tasks <- func(context.Context) error { return taskErr }:
+				}
+			}
+		}()
+
+		report, err := RunStream(context.Background(), tasks, Options{Workers: 1, MaxErrors: 3})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.ErrorIs(t, err, taskErr)
+		require.Equal(t, StreamReport{Consumed: 3, Failed: 3}, report)
+
+		var runErr *RunError
+		require.ErrorAs(t, err, &runErr)
+		require.Len(t, runErr.Errors, 3)
+		for i, e := range runErr.Errors {
+			require.Equal(t, i, e.Index)
+		}
+	})
+
+	t.Run("cancel stops consuming", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		tasks := make(chan ContextTask)
+
+		go func() {
+			tasks <- func(context.Context) error {
+				cancel()
+				return nil
+			}
+		}()
+
+		report, err := RunStream(ctx, tasks, Options{Workers: 2, MaxErrors: 1})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, StreamReport{Consumed: 1, Succeeded: 1}, report)
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		var runTasksCount int32
+		seq := func(yield func(ContextTask) bool) {
+			for i := 0; i < 20; i++ {
+				task := func(context.Context) error {
+					atomic.AddInt32(&runTasksCount, 1)
+					if i%2 == 0 {
+						return fmt.Errorf("error from task %d", i)
+					}
+					return nil
+				}
+				if !yield(task) {
+					return
+				}
+			}
+		}
+
+		report, err := RunSeq(context.Background(), seq, Options{Workers: 4, MaxErrors: -1})
+		require.NoError(t, err)
+		require.Equal(t, StreamReport{Consumed: 20, Succeeded: 10, Failed: 10}, report)
+		require.Equal(t, int32(20), runTasksCount)
+
+		report, err = RunSeq(context.Background(), seq, Options{Workers: 1, MaxErrors: 2})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.Equal(t, StreamReport{Consumed: 3, Succeeded: 1, Failed: 2}, report)
+	})
+}
+
+func TestRunRetry(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errTransient := errors.New("transient error")
+	errFatal := errors.New("fatal error")
+
+	// failingTask fails with err the first failures runs.
+	failingTask := func(failures int32, err error) (ContextTask, *int32) {
+		var runs int32
+		return func(context.Context) error {
+			if atomic.AddInt32(&runs, 1) <= failures {
+				return err
+			}
+			return nil
+		}, &runs
+	}
+
+	retry := &RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		Jitter:      0.5,
+		Retryable: func(err error) bool {
+			return errors.Is(err, errTransient)
+		},
+	}
+
+	t.Run("retried tasks don't use errors limit", func(t *testing.T) {
+		tasks := make([]ContextTask, 0, 10)
+		for i := 0; i < 10; i++ {
+			task, _ := failingTask(2, errTransient)
+			tasks = append(tasks, task)
+		}
+
+		report, err := RunWithReport(context.Background(), tasks, Options{Workers: 3, MaxErrors: 1, Retry: retry})
+		require.NoError(t, err)
+		require.Equal(t, 10, report.Count(TaskSucceeded))
+		require.Equal(t, 20, report.Retries())
+		for _, res := range report.Results {
+			require.Equal(t, TaskResult{Status: TaskSucceeded, Retries: 2}, res)
+		}
+	})
+
+	t.Run("last failure is counted", func(t *testing.T) {
+		transient, transientRuns := failingTask(5, errTransient)
+		fatal, fatalRuns := failingTask(5, errFatal)
+
+		report, err := RunWithReport(context.Background(), []ContextTask{transient, fatal},
+			Options{Workers: 1, MaxErrors: 2, Retry: retry})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.ErrorIs(t, err, errTransient)
+		require.ErrorIs(t, err, errFatal)
+
+		require.Equal(t, TaskResult{Status: TaskFailed, Err: errTransient, Retries: 2}, report.Results[0])
+		require.Equal(t, TaskResult{Status: TaskFailed, Err: errFatal}, report.Results[1])
+		require.Equal(t, int32(3), atomic.LoadInt32(transientRuns))
+		require.Equal(t, int32(1), atomic.LoadInt32(fatalRuns))
+	})
+
+	t.Run("stream report", func(t *testing.T) {
+		tasks := make(chan ContextTask, 2)
+		task, _ := failingTask(1, errTransient)
+		tasks <- task
+		task, _ = failingTask(0, nil)
+		tasks <- task
+		close(tasks)
+
+		report, err := RunStream(context.Background(), tasks, Options{Workers: 2, MaxErrors: 1, Retry: retry})
+		require.NoError(t, err)
+		require.Equal(t, StreamReport{Consumed: 2, Succeeded: 2, Retries: 1}, report)
+	})
+
+	t.Run("cancel stops waiting for retry", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		task := func(context.Context) error {
+			cancel()
+			return errTransient
+		}
+
+		start := time.Now()
+		report, err := RunWithReport(ctx, []ContextTask{task}, Options{
+			Workers:   1,
+			MaxErrors: -1,
+			Retry:     &RetryPolicy{MaxAttempts: 2, Backoff: time.Hour},
+		})
+		require.NoError(t, err)
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, TaskResult{Status: TaskFailed, Err: errTransient}, report.Results[0])
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		p := &RetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+		for retries, delay := range []time.Duration{10, 20, 40, 50, 50} {
+			require.Equal(t, delay*time.Millisecond, p.delay(retries))
+		}
+
+		p.Jitter = 0.5
+		for i := 0; i < 100; i++ {
+			d := p.delay(1)
+			require.GreaterOrEqual(t, d, 10*time.Millisecond)
+			require.LessOrEqual(t, d, 20*time.Millisecond)
+		}
+	})
+
+	t.Run("backoff without max", func(t *testing.T) {
+		p := &RetryPolicy{Backoff: 10 * time.Millisecond}
+		for retries := 0; retries < 40; retries++ {
+			require.Equal(t, 10*time.Millisecond<<retries, p.delay(retries))
+		}
+		for _, retries := range []int{63, 100, math.MaxInt32} {
+			require.Equal(t, time.Duration(math.MaxInt64), p.delay(retries))
+		}
+
+		p.Jitter = 1
+		for i := 0; i < 100; i++ {
+			require.GreaterOrEqual(t, p.delay(100), time.Duration(0))
+		}
+	})
+}
+
+func TestRunLimits(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("rate limit", func(t *testing.T) {
+		tasks := make([]ContextTask, 10)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error { return nil }
+		}
+
+		start := time.Now()
+		err := RunContext(context.Background(), tasks, Options{Workers: 10, MaxErrors: -1, RateLimit: 100, Burst: 2})
+		require.NoError(t, err)
+		// two tasks start at once, every next one waits for 10ms
+		require.GreaterOrEqual(t, time.Since(start), 70*time.Millisecond)
+	})
+
+	t.Run("rate limit with errors limit", func(t *testing.T) {
+		var runTasksCount int32
+		tasks := make([]ContextTask, 10)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error {
+				atomic.AddInt32(&runTasksCount, 1)
+				return errors.New("task error")
+			}
+		}
+
+		start := time.Now()
+		err := RunContext(context.Background(), tasks, Options{Workers: 10, MaxErrors: 3, RateLimit: 50})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.Equal(t, int32(3), runTasksCount)
+		require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("cancel stops waiting for rate limit", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		tasks := make([]ContextTask, 2)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error { return nil }
+		}
+
+		start := time.Now()
+		report, err := RunWithReport(ctx, tasks, Options{Workers: 2, MaxErrors: -1, RateLimit: 0.1})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, 1, report.Count(TaskSucceeded))
+	})
+
+	t.Run("weighted tasks", func(t *testing.T) {
+		workersCount := 4
+		weights := []int{1, 4, 2, 2, 1, 1, 10, 3, 0, 1}
+
+		var (
+			slots    int32
+			maxSlots int32
+			heavy    int32
+		)
+		tasks := make([]ContextTask, len(weights))
+		for i := range tasks {
+			weight := min(max(weights[i], 1), workersCount)
+			tasks[i] = func(context.Context) error {
+				used := atomic.AddInt32(&slots, int32(weight))
+				defer atomic.AddInt32(&slots, -int32(weight))
+
+				for {
+					current := atomic.LoadInt32(&maxSlots)
+					if used <= current || atomic.CompareAndSwapInt32(&maxSlots, current, used) {
+						break
+					}
+				}
+				if weight == workersCount && used != int32(weight) {
+					atomic.StoreInt32(&heavy, 1)
+				}
+
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			}
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   workersCount,
+			MaxErrors: 1,
+			Weight:    func(index int) int { return weights[index] },
+		})
+		require.NoError(t, err)
+		require.LessOrEqual(t, maxSlots, int32(workersCount))
+		require.Zero(t, heavy, "heavy task shared workers with others")
+	})
+}
+
+func TestRunErrorRate(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errTask := errors.New("task error")
+
+	// tasks fail if failed returns true for their index.
+	tasks := func(count int, failed func(i int) bool) []ContextTask {
+		tasks := make([]ContextTask, count)
+		for i := range tasks {
+			err := errTask
+			if !failed(i) {
+				err = nil
+			}
+			tasks[i] = func(context.Context) error { return err }
+		}
+		return tasks
+	}
+
+	t.Run("early failures are ignored", func(t *testing.T) {
+		report, err := RunWithReport(context.Background(), tasks(100, func(i int) bool { return i < 5 }), Options{
+			Workers:   1,
+			MaxErrors: -1,
+			ErrorRate: &ErrorRate{Threshold: 0.5, Window: 10},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 5, report.Count(TaskFailed))
+	})
+
+	t.Run("rate exceeded", func(t *testing.T) {
+		// two of every three tasks fail after the first 50 ones
+		failed := func(i int) bool { return i >= 50 && i%3 != 0 }
+		report, err := RunWithReport(context.Background(), tasks(100, failed), Options{
+			Workers:   1,
+			MaxErrors: -1,
+			ErrorRate: &ErrorRate{Threshold: 0.5, Window: 10},
+		})
+		require.ErrorIs(t, err, ErrErrorRateExceeded)
+		require.NotErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.ErrorIs(t, err, errTask)
+		require.True(t, strings.HasPrefix(err.Error(), ErrErrorRateExceeded.Error()))
+
+		// the 6th failure of the last 10 tasks is the task 58
+		require.Equal(t, 59, report.Count(TaskSucceeded)+report.Count(TaskFailed))
+		require.Equal(t, 6, report.Count(TaskFailed))
+	})
+
+	t.Run("errors limit is still checked", func(t *testing.T) {
+		_, err := RunWithReport(context.Background(), tasks(100, func(i int) bool { return i%4 == 0 }), Options{
+			Workers:   2,
+			MaxErrors: 10,
+			ErrorRate: &ErrorRate{Threshold: 0.5, Window: 10},
+		})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.NotErrorIs(t, err, ErrErrorRateExceeded)
+	})
+}
+
+func TestRunPriority(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	priorities := []int{0, 1, 0, 5, 1, 5}
+
+	var (
+		mu    sync.Mutex
+		order []int
+	)
+	tasks := make([]ContextTask, len(priorities))
+	for i := range tasks {
+		tasks[i] = func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, i)
+			return nil
+		}
+	}
+
+	var weighted []int
+	report, err := RunWithReport(context.Background(), tasks, Options{
+		Workers:   1,
+		MaxErrors: 1,
+		Priority:  func(index int) int { return priorities[index] },
+		Weight: func(index int) int {
+			weighted = append(weighted, index)
+			return 1
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 5, 1, 4, 0, 2}, order)
+	require.Equal(t, order, weighted)
+	require.Equal(t, len(tasks), report.Count(TaskSucceeded))
+}
+
+type testObserver struct {
+	mu       sync.Mutex
+	started  []int
+	finished map[int]TaskResult
+	failed   map[int]error
+	progress []Progress
+}
+
+func newTestObserver() *testObserver {
+	return &testObserver{
+		finished: make(map[int]TaskResult),
+		failed:   make(map[int]error),
+	}
+}
+
+func (o *testObserver) TaskStarted(index int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started = append(o.started, index)
+}
+
+func (o *testObserver) TaskFinished(index int, res TaskResult, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished[index] = res
+}
+
+func (o *testObserver) TaskFailed(index int, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.failed[index] = err
+}
+
+func (o *testObserver) Progress(p Progress) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.progress = append(o.progress, p)
+}
+
+func TestRunObserver(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errTask := errors.New("task error")
+	tasks := make([]ContextTask, 10)
+	for i := range tasks {
+		tasks[i] = func(context.Context) error {
+			time.Sleep(5 * time.Millisecond)
+			if i%3 == 0 {
+				return errTask
+			}
+			return nil
+		}
+	}
+
+	t.Run("tasks and progress", func(t *testing.T) {
+		o := newTestObserver()
+		report, err := RunWithReport(context.Background(), tasks, Options{
+			Workers:          2,
+			MaxErrors:        -1,
+			Observer:         o,
+			ProgressInterval: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, o.started)
+		require.Len(t, o.finished, len(tasks))
+		for i, res := range report.Results {
+			require.Equal(t, res, o.finished[i])
+		}
+		require.Equal(t, map[int]error{0: errTask, 3: errTask, 6: errTask, 9: errTask}, o.failed)
+
+		require.Greater(t, len(o.progress), 1, "no periodic progress")
+		for _, p := range o.progress {
+			require.LessOrEqual(t, p.InFlight, 2)
+			require.Equal(t, len(tasks), p.Done+p.InFlight+p.Remaining)
+		}
+
+		last := o.progress[len(o.progress)-1]
+		require.Equal(t, 10, last.Done)
+		require.Equal(t, 4, last.Failed)
+		require.Zero(t, last.InFlight)
+		require.Zero(t, last.Remaining)
+		require.Positive(t, last.Elapsed)
+		require.Positive(t, last.Throughput)
+	})
+
+	t.Run("stream progress", func(t *testing.T) {
+		o := newTestObserver()
+		_, err := RunSeq(context.Background(), func(yield func(ContextTask) bool) {
+			for _, task := range tasks {
+				if !yield(task) {
+					return
+				}
+			}
+		}, Options{Workers: 2, MaxErrors: 2, Observer: o})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+
+		require.Len(t, o.progress, 1)
+		require.Equal(t, -1, o.progress[0].Remaining)
+		require.Equal(t, 2, o.progress[0].Failed)
+		require.Equal(t, len(o.started), o.progress[0].Done)
+	})
+
+	t.Run("task indexes with priority", func(t *testing.T) {
+		o := newTestObserver()
+		report, err := RunWithReport(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: -1,
+			Priority:  func(index int) int { return index },
+			Observer:  o,
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}, o.started)
+		for i, res := range report.Results {
+			require.Equal(t, res, o.finished[i])
+		}
+		require.Equal(t, map[int]error{0: errTask, 3: errTask, 6: errTask, 9: errTask}, o.failed)
+	})
+
+	t.Run("expvar", func(t *testing.T) {
+		o := NewExpvarObserver("hw05_test_run")
+		err := RunContext(context.Background(), tasks, Options{Workers: 2, MaxErrors: -1, Observer: o})
+		require.NoError(t, err)
+
+		vars := o.Vars()
+		require.Equal(t, "10", vars.Get("tasks_started").String())
+		require.Equal(t, "10", vars.Get("tasks_finished").String())
+		require.Equal(t, "4", vars.Get("tasks_failed").String())
+		require.Equal(t, "10", vars.Get("progress_done").String())
+		require.Equal(t, "0", vars.Get("progress_remaining").String())
+
+		require.Same(t, vars, NewExpvarObserver("hw05_test_run").Vars())
+	})
+}
+
+func TestRunPanic(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errPanic := errors.New("panic error")
+	tasks := []ContextTask{
+		func(context.Context) error { return nil },
+		func(context.Context) error { panic("task panic") },
+		func(context.Context) error { return nil },
+		func(context.Context) error { panic(errPanic) },
+	}
+
+	t.Run("panics are task errors", func(t *testing.T) {
+		report, err := RunWithReport(context.Background(), tasks, Options{Workers: 2, MaxErrors: -1})
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Count(TaskSucceeded))
+
+		var panicErr *PanicError
+		require.ErrorAs(t, report.Results[1].Err, &panicErr)
+		require.Equal(t, "task panic", panicErr.Value)
+		require.Contains(t, string(panicErr.Stack), "TestRunPanic")
+		require.Contains(t, panicErr.Error(), "task panicked: task panic")
+
+		require.ErrorIs(t, report.Results[3].Err, errPanic)
+	})
+
+	t.Run("panics count against errors limit", func(t *testing.T) {
+		err := RunContext(context.Background(), tasks, Options{Workers: 1, MaxErrors: 1})
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+
+		var runErr *RunError
+		require.ErrorAs(t, err, &runErr)
+		require.Len(t, runErr.Errors, 1)
+		require.Equal(t, 1, runErr.Errors[0].Index)
+	})
+
+	t.Run("panics with timeout", func(t *testing.T) {
+		report, err := RunWithReport(context.Background(), tasks, Options{
+			Workers:     2,
+			MaxErrors:   -1,
+			TaskTimeout: time.Second,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Count(TaskFailed))
+	})
+
+	t.Run("repanic after run", func(t *testing.T) {
+		var finished int32
+		slow := func(context.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&finished, 1)
+			return nil
+		}
+
+		defer func() {
+			r := recover()
+			require.NotNil(t, r)
+
+			panicErr, ok := r.(*PanicError)
+			require.True(t, ok)
+			require.Equal(t, "task panic", panicErr.Value)
+			require.Equal(t, int32(2), atomic.LoadInt32(&finished), "panic before workers stopped")
+		}()
+
+		_, _ = RunWithReport(context.Background(), []ContextTask{slow, tasks[1], slow}, Options{
+			Workers:   3,
+			MaxErrors: -1,
+			Repanic:   true,
+		})
+		require.Fail(t, "run didn't panic")
+	})
+}
@@ -0,0 +1,78 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"iter"
+	"sort"
+	"sync"
+)
+
+// StreamReport describes a run of tasks taken from a channel or an iterator.
+type StreamReport struct {
+	// Consumed is the number of tasks taken from the source. Tasks taken after
+	// the errors limit was exceeded are counted here but not started.
+	Consumed  int
+	Succeeded int
+	Failed    int
+	Retries   int
+}
+
+// RunStream is RunContext for tasks received from a channel. A task is received only
+// when a worker is free to start it, so a producer sending into an unbuffered channel
+// is blocked while all workers are busy. The run ends when tasks is closed,
+// ctx is done or the errors limit is exceeded; the channel is not drained after that.
+// Task indexes in *RunError are the order in which tasks were received.
+func RunStream(ctx context.Context, tasks <-chan ContextTask, opts Options) (StreamReport, error) {
+	return runStream(ctx, opts, func(ctx context.Context) (ContextTask, bool) {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case task, ok := <-tasks:
+			return task, ok
+		}
+	})
+}
+
+// RunSeq is RunStream for tasks produced by an iterator. The iterator is advanced
+// only when a worker is free and is stopped when the run ends.
+func RunSeq(ctx context.Context, tasks iter.Seq[ContextTask], opts Options) (StreamReport, error) {
+	next, stop := iter.Pull(tasks)
+	defer stop()
+
+	return runStream(ctx, opts, func(context.Context) (ContextTask, bool) {
+		return next()
+	})
+}
+
+func runStream(ctx context.Context, opts Options, next source) (StreamReport, error) {
+	var (
+		mu     sync.Mutex
+		report StreamReport
+		errs   []*TaskError
+	)
+
+	e := newExecutor(opts, nil, func(index int, res TaskResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		report.Retries += res.Retries
+		if res.Status == TaskSucceeded {
+			report.Succeeded++
+			return
+		}
+
+		report.Failed++
+		// without a limit the run may be endless, so errors are not kept
+		if opts.MaxErrors > 0 {
+			errs = append(errs, &TaskError{Index: index, Err: res.Err})
+		}
+	})
+
+	e.run(ctx, next)
+	report.Consumed = e.consumed
+
+	return report, e.err(ctx, func() []*TaskError {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Index < errs[j].Index })
+		return errs
+	})
+}
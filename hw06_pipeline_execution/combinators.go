@@ -0,0 +1,261 @@
+package hw06pipelineexecution
+
+import "time"
+
+// combine starts body in a goroutine with a new output channel. When body returns the output is closed
+// and in is drained, so the previous stages are not blocked.
+func combine(in In, body func(out Bi)) Out {
+	out := make(Bi)
+
+	go func() {
+		defer func() {
+			close(out)
+			for range in {
+				continue
+			}
+		}()
+
+		body(out)
+	}()
+
+	return out
+}
+
+// send returns false if done is closed before v is sent.
+func send(done In, out Bi, v interface{}) bool {
+	select {
+	case <-done:
+		return false
+	case out <- v:
+		return true
+	}
+}
+
+// Map returns a stage sending f(v) for every item.
+func Map(done In, f func(v interface{}) interface{}) Stage {
+	return func(in In) Out {
+		return combine(in, func(out Bi) {
+			for {
+				select {
+				case <-done:
+					return
+				case v, ok := <-in:
+					if !ok || !send(done, out, f(v)) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+// Filter returns a stage passing on items for which keep returns true.
+func Filter(done In, keep func(v interface{}) bool) Stage {
+	return func(in In) Out {
+		return combine(in, func(out Bi) {
+			for {
+				select {
+				case <-done:
+					return
+				case v, ok := <-in:
+					if !ok {
+						return
+					}
+					if keep(v) && !send(done, out, v) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+// Tee returns a stage passing on every item and sending it to side before that.
+// The stage closes side when it finishes.
+func Tee(done In, side Bi) Stage {
+	return func(in In) Out {
+		return combine(in, func(out Bi) {
+			defer close(side)
+
+			for {
+				select {
+				case <-done:
+					return
+				case v, ok := <-in:
+					if !ok || !send(done, side, v) || !send(done, out, v) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+// Batch returns a stage collecting items into []interface{} batches of size items.
+// A batch is sent earlier if maxWait has passed since its first item, zero maxWait means no limit.
+// The last batch is sent when the input is closed, an incomplete batch is dropped when done is closed.
+func Batch(done In, size int, maxWait time.Duration) Stage {
+	size = max(size, 1)
+
+	return func(in In) Out {
+		return combine(in, func(out Bi) {
+			var (
+				batch []interface{}
+				timer *time.Timer
+				wait  <-chan time.Time
+			)
+
+			flush := func() bool {
+				if timer != nil {
+					timer.Stop()
+					timer, wait = nil, nil
+				}
+				if len(batch) == 0 {
+					return true
+				}
+				b := batch
+				batch = nil
+				return send(done, out, b)
+			}
+
+			for {
+				select {
+				case <-done:
+					return
+				case <-wait:
+					timer, wait = nil, nil
+					if !flush() {
+						return
+					}
+				case v, ok := <-in:
+					if !ok {
+						flush()
+						return
+					}
+
+					batch = append(batch, v)
+					if len(batch) == 1 && maxWait > 0 {
+						timer = time.NewTimer(maxWait)
+						wait = timer.C
+					}
+					if len(batch) == size && !flush() {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+// Unbatch returns a stage sending every item of []interface{} batches separately.
+// Items which aren't batches are sent as is.
+func Unbatch(done In) Stage {
+	return func(in In) Out {
+		return combine(in, func(out Bi) {
+			for {
+				select {
+				case <-done:
+					return
+				case batch, ok := <-in:
+					if !ok {
+						return
+					}
+					items, isBatch := batch.([]interface{})
+					if !isBatch {
+						items = []interface{}{batch}
+					}
+					for _, v := range items {
+						if !send(done, out, v) {
+							return
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// minWindowStep is the shortest period of window stages, shorter ones are raised to it.
+const minWindowStep = time.Millisecond
+
+// TumblingWindow returns a stage sending items received during every size period as []interface{}.
+// Empty windows are not sent, the items of the current window are sent when the input is closed.
+// A size shorter than a millisecond is raised to it.
+func TumblingWindow(done In, size time.Duration) Stage {
+	return SlidingWindow(done, size, size)
+}
+
+// SlidingWindow returns a stage sending items received during the last size period every step as []interface{},
+// so an item is sent in size/step windows. Windows without new items are not sent,
+// the current window is sent when the input is closed if it has new items.
+// A step longer than size leaves gaps between windows, items received in the gaps are not sent.
+// A step shorter than a millisecond is raised to it, a non-positive size means size equal to step.
+func SlidingWindow(done In, size, step time.Duration) Stage {
+	step = max(step, minWindowStep)
+	if size <= 0 {
+		size = step
+	}
+
+	type timed struct {
+		v  interface{}
+		at time.Time
+	}
+
+	return func(in In) Out {
+		return combine(in, func(out Bi) {
+			ticker := time.NewTicker(step)
+			defer ticker.Stop()
+
+			var (
+				items  []timed
+				unsent int // index of the first item which was not sent in a window yet
+			)
+
+			// emit sends items received during size before now. Windows without gaps also get all unsent items,
+			// so a late tick doesn't lose items.
+			emit := func(now time.Time) bool {
+				start := 0
+				for start < len(items) && (start < unsent || step > size) && !items[start].at.After(now.Add(-size)) {
+					start++
+				}
+				items = items[start:]
+				unsent = max(unsent-start, 0)
+
+				if unsent == len(items) {
+					// no new items
+					return true
+				}
+				unsent = len(items)
+
+				window := make([]interface{}, len(items))
+				for i, item := range items {
+					window[i] = item.v
+				}
+				return send(done, out, window)
+			}
+
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					now := time.Now()
+					if !emit(now) {
+						return
+					}
+					// items which won't get into the next window
+					for len(items) > 0 && !items[0].at.After(now.Add(step-size)) {
+						items = items[1:]
+						unsent--
+					}
+				case v, ok := <-in:
+					if !ok {
+						emit(time.Now())
+						return
+					}
+					items = append(items, timed{v: v, at: time.Now()})
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type ShutdownMode int
+
+const (
+	// ShutdownAbort drops all items when the context is done.
+	ShutdownAbort ShutdownMode = iota
+	// ShutdownDrain stops taking input when the context is done, items already taken pass the stages
+	// until Options.DrainTimeout expires. The rest of the input is read and dropped.
+	ShutdownDrain
+)
+
+type Options struct {
+	Shutdown ShutdownMode
+	// DrainTimeout limits draining in ShutdownDrain mode, zero means no limit.
+	DrainTimeout time.Duration
+	// Buffers[i] is the number of items which may wait for stage i besides the one being passed to it,
+	// Buffers[len(stages)] is for the output. Missing values mean no buffers.
+	Buffers []int
+	// MaxInFlight stops taking items from the input while this many items wait between stages,
+	// zero means no limit. Items kept inside stages are not counted, so stages may change
+	// the number of items. Items passed between stages never wait for the limit,
+	// so it may be exceeded by the items leaving stages at that moment.
+	MaxInFlight int
+}
+
+func (o Options) buffer(i int) int {
+	if i < len(o.Buffers) {
+		return max(o.Buffers[i], 0)
+	}
+	return 0
+}
+
+// Summary describes a finished pipeline.
+type Summary struct {
+	// Admitted is the number of items taken from the input.
+	Admitted int
+	// Finished[i] is the number of items stage i has passed on.
+	Finished []int
+	// Aborted reports whether items were dropped on shutdown.
+	Aborted bool
+}
+
+// ExecutePipelineWithOptions is ExecutePipeline with buffers and the in-flight limit of opts.
+// Closing done stops the pipeline as opts.Shutdown says.
+func ExecutePipelineWithOptions(in, done In, opts Options, stages ...Stage) Out {
+	ctx, cancel := context.WithCancel(context.Background())
+	out, summaries := ExecutePipelineContext(ctx, in, opts, stages...)
+
+	go func() {
+		defer cancel()
+		select {
+		case <-done:
+		case <-summaries:
+		}
+	}()
+
+	return out
+}
+
+// ExecutePipelineContext is ExecutePipeline stopped by ctx as opts.Shutdown says.
+// The summary is sent to the returned channel after the output is closed.
+func ExecutePipelineContext(ctx context.Context, in In, opts Options, stages ...Stage) (Out, <-chan Summary) {
+	summaries := make(chan Summary, 1)
+
+	if in == nil {
+		res := make(Bi)
+		close(res)
+		summaries <- Summary{Finished: make([]int, len(stages))}
+		return res, summaries
+	}
+
+	abort := make(chan struct{})
+	finished := make(chan struct{})
+	go watchShutdown(ctx, opts, abort, finished)
+
+	counts := make([]int, len(stages)+1)
+	wg := sync.WaitGroup{}
+
+	limit := newInFlightLimit(opts.MaxInFlight)
+
+	// forward passes items from prev to stage i, or to the output for i == len(stages),
+	// counting them in counts[i]. It keeps up to opts.buffer(i)+1 items, so it knows when
+	// an item has left the link. The input forwarder stops taking items when ctx is done
+	// or the in-flight limit is reached.
+	forward := func(i int, prev Out) Out {
+		next := make(Bi)
+		size := opts.buffer(i) + 1
+
+		var stop <-chan struct{}
+		if i == 0 {
+			stop = ctx.Done()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var queue []interface{}
+			defer func() {
+				limit.release(len(queue))
+				close(next)
+				for range prev {
+					continue
+				}
+			}()
+
+			open := true
+			for open || len(queue) > 0 {
+				var (
+					recv   Out
+					freed  <-chan struct{}
+					send   Bi
+					head   interface{}
+					isFull = i == 0 && limit.full()
+				)
+				if open && len(queue) < size {
+					if isFull {
+						freed = limit.freed
+					} else {
+						recv = prev
+					}
+				}
+				if len(queue) > 0 {
+					send, head = next, queue[0]
+				}
+
+				select {
+				case <-abort:
+					return
+				case <-stop:
+					stop, open = nil, false
+				case <-freed:
+				case elem, ok := <-recv:
+					if !ok {
+						open = false
+						continue
+					}
+					limit.take()
+					queue = append(queue, elem)
+					counts[i]++
+				case send <- head:
+					queue = queue[1:]
+					limit.release(1)
+				}
+			}
+		}()
+
+		return next
+	}
+
+	prevOut := in
+	for i, stage := range stages {
+		prevOut = stage(forward(i, prevOut))
+	}
+	out := forward(len(stages), prevOut)
+
+	go func() {
+		wg.Wait()
+		close(finished)
+
+		aborted := false
+		select {
+		case <-abort:
+			aborted = true
+		default:
+		}
+
+		summaries <- Summary{
+			Admitted: counts[0],
+			Finished: counts[1:],
+			Aborted:  aborted,
+		}
+	}()
+
+	return out, summaries
+}
+
+// inFlightLimit counts items waiting between stages.
+type inFlightLimit struct {
+	mu    sync.Mutex
+	max   int
+	count int
+	freed chan struct{} // signalled when count is decreased
+}
+
+// newInFlightLimit returns nil, which is never full, if max is not positive.
+func newInFlightLimit(size int) *inFlightLimit {
+	if size <= 0 {
+		return nil
+	}
+	return &inFlightLimit{max: size, freed: make(chan struct{}, 1)}
+}
+
+func (l *inFlightLimit) full() bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count >= l.max
+}
+
+func (l *inFlightLimit) take() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.count++
+}
+
+func (l *inFlightLimit) release(n int) {
+	if l == nil || n == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.count -= n
+
+	select {
+	case l.freed <- struct{}{}:
+	default:
+	}
+}
+
+// watchShutdown closes abort when ctx is done in ShutdownAbort mode
+// or when the drain timeout expires after that in ShutdownDrain mode.
+func watchShutdown(ctx context.Context, opts Options, abort chan<- struct{}, finished <-chan struct{}) {
+	select {
+	case <-finished:
+		return
+	case <-ctx.Done():
+	}
+
+	if opts.Shutdown == ShutdownDrain {
+		if opts.DrainTimeout <= 0 {
+			return
+		}
+
+		timer := time.NewTimer(opts.DrainTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-finished:
+			return
+		case <-timer.C:
+		}
+	}
+
+	close(abort)
+}
@@ -0,0 +1,232 @@
+package hw06pipelineexecution
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var DefaultBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// MetricsSink receives measurements of instrumented stages as they happen.
+// Its methods are called concurrently from different stages.
+type MetricsSink interface {
+	ItemIn(stage string)
+	// ItemOut is called when the stage has passed an item on. processing is the time since
+	// the matching input item entered the stage, it is zero for stages not marked WithOneToOne.
+	// blocked is the time spent waiting for the next stage.
+	ItemOut(stage string, processing, blocked time.Duration)
+}
+
+// Histogram counts durations by buckets, Counts[i] is the number of durations not greater than Bounds[i],
+// the last count is for durations greater than all bounds.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []int64
+	Count  int64
+	Sum    time.Duration
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(h.Bounds) && d > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+// StageMetrics is a snapshot of the measurements of a stage.
+type StageMetrics struct {
+	Name string
+	In   int64
+	Out  int64
+	// InFlight is the number of items which entered the stage and haven't left it yet.
+	// Stages not marked WithOneToOne may drop or join items, for them it is the number of items
+	// which entered since the last result. It is zero after the stage has closed its output.
+	InFlight int64
+	// Processing is the time from an item entering the stage to its result leaving it,
+	// it is measured only for stages marked WithOneToOne.
+	Processing Histogram
+	// Blocked is the total time the stage results waited for the next stage.
+	Blocked time.Duration
+}
+
+type InstrumentationOption func(i *Instrumentation)
+
+// WithSink passes measurements to sink in addition to the snapshot counters.
+func WithSink(sink MetricsSink) InstrumentationOption {
+	return func(i *Instrumentation) {
+		i.sink = sink
+	}
+}
+
+// WithBuckets sets ascending bounds of the processing time histogram, DefaultBuckets are used by default.
+func WithBuckets(bounds ...time.Duration) InstrumentationOption {
+	return func(i *Instrumentation) {
+		i.buckets = bounds
+	}
+}
+
+// Instrumentation measures stages wrapped by Stage.
+type Instrumentation struct {
+	mu      sync.Mutex
+	stages  []*StageMetrics
+	sink    MetricsSink
+	buckets []time.Duration
+}
+
+func NewInstrumentation(opts ...InstrumentationOption) *Instrumentation {
+	i := &Instrumentation{buckets: DefaultBuckets}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+type StageOption func(s *stageOptions)
+
+type stageOptions struct {
+	oneToOne bool
+}
+
+// WithOneToOne marks a stage returning exactly one result for every input item in their order,
+// like Map, so every result is matched with its input item to measure the processing time.
+func WithOneToOne() StageOption {
+	return func(s *stageOptions) {
+		s.oneToOne = true
+	}
+}
+
+// Stage returns stage which is measured under name. An empty name is replaced with "stage N",
+// where N is the number of the stage in this instrumentation starting from 0.
+func (i *Instrumentation) Stage(name string, stage Stage, opts ...StageOption) Stage {
+	var so stageOptions
+	for _, opt := range opts {
+		opt(&so)
+	}
+
+	i.mu.Lock()
+	if name == "" {
+		name = fmt.Sprintf("stage %d", len(i.stages))
+	}
+	m := &StageMetrics{
+		Name: name,
+		Processing: Histogram{
+			Bounds: i.buckets,
+			Counts: make([]int64, len(i.buckets)+1),
+		},
+	}
+	i.stages = append(i.stages, m)
+	i.mu.Unlock()
+
+	return func(in In) Out {
+		var (
+			mu      sync.Mutex
+			entered []time.Time
+		)
+
+		stageIn := make(Bi)
+		go func() {
+			defer close(stageIn)
+			for v := range in {
+				if so.oneToOne {
+					mu.Lock()
+					entered = append(entered, time.Now())
+					mu.Unlock()
+				}
+
+				i.itemIn(m)
+				stageIn <- v
+			}
+		}()
+
+		stageOut := stage(stageIn)
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			for v := range stageOut {
+				var processing time.Duration
+
+				mu.Lock()
+				if len(entered) > 0 {
+					processing = time.Since(entered[0])
+					entered = entered[1:]
+				}
+				mu.Unlock()
+
+				start := time.Now()
+				out <- v
+				i.itemOut(m, so.oneToOne, processing, time.Since(start))
+			}
+
+			mu.Lock()
+			entered = nil
+			mu.Unlock()
+			i.stageClosed(m)
+		}()
+
+		return out
+	}
+}
+
+// Snapshot returns the measurements of all stages in order of their creation.
+func (i *Instrumentation) Snapshot() []StageMetrics {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	snapshot := make([]StageMetrics, len(i.stages))
+	for j, m := range i.stages {
+		snapshot[j] = *m
+		snapshot[j].Processing.Counts = append([]int64(nil), m.Processing.Counts...)
+	}
+	return snapshot
+}
+
+func (i *Instrumentation) itemIn(m *StageMetrics) {
+	i.mu.Lock()
+	m.In++
+	m.InFlight++
+	i.mu.Unlock()
+
+	if i.sink != nil {
+		i.sink.ItemIn(m.Name)
+	}
+}
+
+// itemOut counts the result, processing is observed only for one-to-one stages.
+func (i *Instrumentation) itemOut(m *StageMetrics, oneToOne bool, processing, blocked time.Duration) {
+	i.mu.Lock()
+	m.Out++
+	if oneToOne {
+		m.InFlight = max(m.InFlight-1, 0)
+		m.Processing.observe(processing)
+	} else {
+		m.InFlight = 0
+	}
+	m.Blocked += blocked
+	i.mu.Unlock()
+
+	if i.sink != nil {
+		i.sink.ItemOut(m.Name, processing, blocked)
+	}
+}
+
+// stageClosed clears InFlight once the stage can't return more results.
+func (i *Instrumentation) stageClosed(m *StageMetrics) {
+	i.mu.Lock()
+	m.InFlight = 0
+	i.mu.Unlock()
+}
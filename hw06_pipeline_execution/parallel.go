@@ -0,0 +1,187 @@
+package hw06pipelineexecution
+
+import "sync"
+
+type Order int
+
+const (
+	// OrderInput emits results in the order of their input items.
+	// The stage must return exactly one item for every input item in the same order.
+	// At most reorderWindow items per worker are taken ahead of the oldest result not emitted yet,
+	// so an item lost by the stage stalls the input until the stage output is closed or done is closed.
+	OrderInput Order = iota
+	// OrderCompletion emits results as soon as workers return them.
+	OrderCompletion
+)
+
+// Parallel returns a stage running workers copies of stage, every copy takes the next item when it is free.
+// When done is closed the items waiting for a free copy are dropped, the output is closed
+// after all copies have finished their current items.
+func Parallel(done In, stage Stage, workers int, order Order) Stage {
+	workers = max(workers, 1)
+
+	return func(in In) Out {
+		if order == OrderCompletion {
+			outs := make([]Out, workers)
+			for i := range outs {
+				outs[i] = stage(in)
+			}
+			return merge(outs)
+		}
+
+		return ordered(done, in, stage, workers)
+	}
+}
+
+// merge sends items of all outs to a single channel.
+func merge(outs []Out) Out {
+	res := make(Bi)
+	wg := sync.WaitGroup{}
+
+	for _, out := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range out {
+				res <- v
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(res)
+	}()
+
+	return res
+}
+
+// reorderWindow is the number of items per worker which may be taken ahead of the next result in OrderInput.
+const reorderWindow = 4
+
+type sequenced struct {
+	seq   int
+	value interface{}
+	ok    bool // false if the item was lost by the stage
+}
+
+// seqQueue holds sequence numbers of items given to a worker and not returned yet.
+type seqQueue struct {
+	mu   sync.Mutex
+	seqs []int
+}
+
+func (q *seqQueue) push(seq int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seqs = append(q.seqs, seq)
+}
+
+func (q *seqQueue) pop() (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.seqs) == 0 {
+		return 0, false
+	}
+
+	seq := q.seqs[0]
+	q.seqs = q.seqs[1:]
+	return seq, true
+}
+
+// ordered numbers input items, runs them in workers copies of stage and
+// restores the input order of the results with a reorder buffer.
+// window bounds the buffer, a slot is taken for every numbered item and freed when its seq is passed.
+func ordered(done, in In, stage Stage, workers int) Out {
+	window := make(chan struct{}, workers*reorderWindow)
+	numbered := make(chan sequenced)
+	go func() {
+		defer func() {
+			close(numbered)
+			for range in {
+				continue
+			}
+		}()
+
+		seq := 0
+		for v := range in {
+			select {
+			case <-done:
+				return
+			case window <- struct{}{}:
+			}
+
+			select {
+			case <-done:
+				return
+			case numbered <- sequenced{seq: seq, value: v, ok: true}:
+			}
+			seq++
+		}
+	}()
+
+	results := make(chan sequenced)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		queue := &seqQueue{}
+		workerIn := make(Bi)
+
+		go func() {
+			defer close(workerIn)
+			for item := range numbered {
+				queue.push(item.seq)
+				select {
+				case <-done:
+					return
+				case workerIn <- item.value:
+				}
+			}
+		}()
+
+		wg.Add(1)
+		go func(out Out) {
+			defer wg.Done()
+			for v := range out {
+				seq, ok := queue.pop()
+				if !ok {
+					// the stage returned more items than it got, the order of extra ones is unknown
+					continue
+				}
+				results <- sequenced{seq: seq, value: v, ok: true}
+			}
+
+			// items which the stage has not returned are skipped
+			for seq, ok := queue.pop(); ok; seq, ok = queue.pop() {
+				results <- sequenced{seq: seq}
+			}
+		}(stage(workerIn))
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	res := make(Bi)
+	go func() {
+		defer close(res)
+
+		next := 0
+		buffer := make(map[int]sequenced)
+		for item := range results {
+			buffer[item.seq] = item
+			for item, ok := buffer[next]; ok; item, ok = buffer[next] {
+				delete(buffer, next)
+				next++
+				<-window
+				if item.ok {
+					res <- item.value
+				}
+			}
+		}
+	}()
+
+	return res
+}
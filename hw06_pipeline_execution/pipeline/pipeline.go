@@ -0,0 +1,231 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Stage converts a single item. An error means the item failed, what happens to it depends on ErrorMode.
+type Stage[In, Out any] func(item In) (Out, error)
+
+type ErrorMode int
+
+const (
+	// ErrorSkip drops the failed item and goes on with the next one.
+	ErrorSkip ErrorMode = iota
+	// ErrorStop stops every stage of the pipeline, their outputs are closed.
+	ErrorStop
+	// ErrorDeadLetter sends the failed item to the channel set by WithDeadLetter and goes on with the next one.
+	// Without the channel it works as ErrorSkip.
+	ErrorDeadLetter
+)
+
+func (m ErrorMode) String() string {
+	switch m {
+	case ErrorSkip:
+		return "skip"
+	case ErrorStop:
+		return "stop"
+	case ErrorDeadLetter:
+		return "dead letter"
+	default:
+		return "unknown"
+	}
+}
+
+// StageError is a failure of the item in the stage with the given index, stages are numbered from 0 in order of Add.
+type StageError struct {
+	Stage int
+	Item  any
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("stage %d: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultMaxErrors is the number of failures a pipeline keeps unless WithMaxErrors is used.
+const DefaultMaxErrors = 100
+
+type Option func(p *Pipeline)
+
+// WithErrorMode sets what happens to failed items, ErrorSkip is used by default.
+func WithErrorMode(mode ErrorMode) Option {
+	return func(p *Pipeline) {
+		p.mode = mode
+	}
+}
+
+// WithMaxErrors sets the number of failures kept for Errors and Err, later ones are only counted by Failed.
+// A negative n means all failures are kept.
+func WithMaxErrors(n int) Option {
+	return func(p *Pipeline) {
+		p.maxErrors = n
+	}
+}
+
+// WithDeadLetter sets ErrorDeadLetter mode with the channel for failed items.
+// A stage waits until the failure is received or the pipeline is stopped.
+func WithDeadLetter(ch chan<- *StageError) Option {
+	return func(p *Pipeline) {
+		p.mode = ErrorDeadLetter
+		p.deadLetter = ch
+	}
+}
+
+// Pipeline connects stages added with Add. All of them stop when done is closed.
+type Pipeline struct {
+	done       <-chan struct{}
+	stop       chan struct{}
+	stopOnce   sync.Once
+	mode       ErrorMode
+	deadLetter chan<- *StageError
+	stages     int
+
+	mu        sync.Mutex
+	errors    []*StageError
+	maxErrors int
+	failed    int
+}
+
+func New(done <-chan struct{}, opts ...Option) *Pipeline {
+	p := &Pipeline{
+		done:      done,
+		stop:      make(chan struct{}),
+		maxErrors: DefaultMaxErrors,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.mode == ErrorDeadLetter && p.deadLetter == nil {
+		p.mode = ErrorSkip
+	}
+
+	return p
+}
+
+// Add starts stage in a goroutine reading in, its results are sent to the returned channel.
+// The channel is closed when in is closed or the pipeline is stopped. After that in is drained,
+// so the previous stages are not blocked. Stages must be added from a single goroutine.
+func Add[In, Out any](p *Pipeline, in <-chan In, stage Stage[In, Out]) <-chan Out {
+	index := p.stages
+	p.stages++
+
+	out := make(chan Out)
+
+	go func() {
+		defer func() {
+			close(out)
+			for range in {
+				continue
+			}
+		}()
+
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-p.stop:
+				return
+			case item, ok := <-in:
+				if !ok {
+					return
+				}
+
+				res, err := stage(item)
+				if err != nil {
+					if !p.fail(&StageError{Stage: index, Item: item, Err: err}) {
+						return
+					}
+					continue
+				}
+
+				select {
+				case <-p.done:
+					return
+				case <-p.stop:
+					return
+				case out <- res:
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Errors returns the failures recorded in ErrorSkip and ErrorStop modes in order of their occurrence,
+// only the first of them are kept, see WithMaxErrors.
+func (p *Pipeline) Errors() []*StageError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]*StageError(nil), p.errors...)
+}
+
+// Failed returns the number of failures in all modes, including the ones which are not kept.
+func (p *Pipeline) Failed() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.failed
+}
+
+// Err joins the recorded failures, it returns nil if there are none.
+func (p *Pipeline) Err() error {
+	p.mu.Lock()
+	errs := append([]*StageError(nil), p.errors...)
+	dropped := p.failed - len(p.errors)
+	if p.mode == ErrorDeadLetter {
+		dropped = 0
+	}
+	p.mu.Unlock()
+
+	if len(errs) == 0 && dropped == 0 {
+		return nil
+	}
+
+	joined := make([]error, 0, len(errs)+1)
+	for _, err := range errs {
+		joined = append(joined, err)
+	}
+	if dropped > 0 {
+		joined = append(joined, fmt.Errorf("%d more failures", dropped))
+	}
+	return errors.Join(joined...)
+}
+
+// fail handles the failure according to the mode, it returns false if the stage should stop.
+func (p *Pipeline) fail(err *StageError) bool {
+	p.mu.Lock()
+	p.failed++
+	if p.mode != ErrorDeadLetter && (p.maxErrors < 0 || len(p.errors) < p.maxErrors) {
+		p.errors = append(p.errors, err)
+	}
+	p.mu.Unlock()
+
+	if p.mode == ErrorDeadLetter {
+		select {
+		case <-p.done:
+			return false
+		case <-p.stop:
+			return false
+		case p.deadLetter <- err:
+			return true
+		}
+	}
+
+	if p.mode == ErrorStop {
+		p.stopOnce.Do(func() { close(p.stop) })
+		return false
+	}
+
+	return true
+}
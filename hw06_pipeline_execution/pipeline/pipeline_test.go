@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errOdd = errors.New("odd number")
+
+func generate(n int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 1; i <= n; i++ {
+			ch <- i
+		}
+	}()
+	return ch
+}
+
+func collect[T any](ch <-chan T) []T {
+	var res []T
+	for v := range ch {
+		res = append(res, v)
+	}
+	return res
+}
+
+func double(v int) (int, error) {
+	return v * 2, nil
+}
+
+func even(v int) (int, error) {
+	if v%2 != 0 {
+		return 0, errOdd
+	}
+	return v, nil
+}
+
+func format(v int) (string, error) {
+	return "#" + strconv.Itoa(v), nil
+}
+
+func TestPipeline(t *testing.T) {
+	t.Run("typed stages", func(t *testing.T) {
+		p := New(nil)
+		out := Add(p, Add(p, generate(5), double), format)
+
+		require.Equal(t, []string{"#2", "#4", "#6", "#8", "#10"}, collect(out))
+		require.NoError(t, p.Err())
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		p := New(nil)
+		out := Add(p, Add(p, generate(5), format), func(v string) (int, error) {
+			n, _ := strconv.Atoi(v[1:])
+			return even(n)
+		})
+
+		require.Equal(t, []int{2, 4}, collect(out))
+
+		errs := p.Errors()
+		require.Len(t, errs, 3)
+		for i, v := range []string{"#1", "#3", "#5"} {
+			require.Equal(t, &StageError{Stage: 1, Item: v, Err: errOdd}, errs[i])
+		}
+		require.ErrorIs(t, p.Err(), errOdd)
+		require.Contains(t, p.Err().Error(), "stage 1: odd number")
+	})
+
+	t.Run("kept errors are limited", func(t *testing.T) {
+		p := New(nil, WithMaxErrors(2))
+		out := Add(p, generate(1000), even)
+
+		require.Len(t, collect(out), 500)
+		require.Equal(t, 500, p.Failed())
+
+		errs := p.Errors()
+		require.Len(t, errs, 2)
+		require.Equal(t, 1, errs[0].Item)
+		require.Equal(t, 3, errs[1].Item)
+		require.ErrorIs(t, p.Err(), errOdd)
+		require.Contains(t, p.Err().Error(), "498 more failures")
+
+		p = New(nil)
+		collect(Add(p, generate(1000), even))
+		require.Len(t, p.Errors(), DefaultMaxErrors)
+
+		p = New(nil, WithMaxErrors(-1))
+		collect(Add(p, generate(1000), even))
+		require.Len(t, p.Errors(), 500)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		p := New(nil, WithErrorMode(ErrorStop))
+		out := Add(p, Add(p, generate(100), even), format)
+
+		res := collect(out)
+		require.LessOrEqual(t, len(res), 1)
+
+		var stageErr *StageError
+		require.ErrorAs(t, p.Err(), &stageErr)
+		require.Equal(t, 0, stageErr.Stage)
+		require.Equal(t, 1, stageErr.Item)
+	})
+
+	t.Run("dead letter", func(t *testing.T) {
+		deadLetter := make(chan *StageError)
+		p := New(nil, WithDeadLetter(deadLetter))
+		out := Add(p, Add(p, generate(6), even), format)
+
+		var failed []any
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for err := range deadLetter {
+				require.Equal(t, 0, err.Stage)
+				require.ErrorIs(t, err, errOdd)
+				failed = append(failed, err.Item)
+			}
+		}()
+
+		require.Equal(t, []string{"#2", "#4", "#6"}, collect(out))
+		close(deadLetter)
+		<-done
+
+		require.Equal(t, []any{1, 3, 5}, failed)
+		require.NoError(t, p.Err())
+		require.Equal(t, 3, p.Failed())
+	})
+
+	t.Run("dead letter without channel", func(t *testing.T) {
+		p := New(nil, WithErrorMode(ErrorDeadLetter))
+		out := Add(p, Add(p, generate(6), even), format)
+
+		require.Equal(t, []string{"#2", "#4", "#6"}, collect(out))
+		require.Len(t, p.Errors(), 3)
+		require.ErrorIs(t, p.Err(), errOdd)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		done := make(chan struct{})
+		p := New(done)
+
+		in := make(chan int)
+		out := Add(p, Add(p, in, double), format)
+
+		go func() {
+			in <- 1
+			close(done)
+		}()
+
+		start := time.Now()
+		require.LessOrEqual(t, len(collect(out)), 1)
+		require.Less(t, time.Since(start), time.Second)
+		close(in)
+	})
+
+	t.Run("error mode string", func(t *testing.T) {
+		for mode, s := range map[ErrorMode]string{ErrorSkip: "skip", ErrorStop: "stop", ErrorDeadLetter: "dead letter"} {
+			require.Equal(t, s, fmt.Sprint(mode))
+		}
+	})
+}
@@ -1,8 +1,11 @@
 package hw06pipelineexecution
 
 import (
+	"context"
+	"math/rand"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -201,3 +204,772 @@ func TestAllStageStop(t *testing.T) {
 		require.Len(t, result, 0)
 	})
 }
+
+func TestParallel(t *testing.T) {
+	// Stage generator with a random delay
+	g := func(f func(v interface{}) interface{}) Stage {
+		return func(in In) Out {
+			out := make(Bi)
+			go func() {
+				defer close(out)
+				for v := range in {
+					time.Sleep(time.Duration(rand.Intn(20)) * time.Millisecond)
+					out <- f(v)
+				}
+			}()
+			return out
+		}
+	}
+
+	send := func(data []int) Bi {
+		in := make(Bi)
+		go func() {
+			defer close(in)
+			for _, v := range data {
+				in <- v
+			}
+		}()
+		return in
+	}
+
+	data := make([]int, 50)
+	expected := make([]int, 50)
+	for i := range data {
+		data[i] = i
+		expected[i] = i*2 + 100
+	}
+
+	stages := func(order Order) []Stage {
+		return []Stage{
+			Parallel(nil, g(func(v interface{}) interface{} { return v.(int) * 2 }), 8, order),
+			g(func(v interface{}) interface{} { return v.(int) + 100 }),
+		}
+	}
+
+	t.Run("input order", func(t *testing.T) {
+		result := make([]int, 0, len(data))
+		start := time.Now()
+		for v := range ExecutePipeline(send(data), nil, stages(OrderInput)...) {
+			result = append(result, v.(int))
+		}
+
+		require.Equal(t, expected, result)
+		// the sequential stage would take ~500ms
+		require.Less(t, time.Since(start), 2*sleepPerStage*time.Duration(len(data))/10)
+	})
+
+	t.Run("completion order", func(t *testing.T) {
+		result := make([]int, 0, len(data))
+		for v := range ExecutePipeline(send(data), nil, stages(OrderCompletion)...) {
+			result = append(result, v.(int))
+		}
+
+		require.ElementsMatch(t, expected, result)
+	})
+
+	t.Run("lost items are skipped", func(t *testing.T) {
+		odd := func(in In) Out {
+			out := make(Bi)
+			go func() {
+				defer close(out)
+				for v := range in {
+					if v.(int)%2 != 0 {
+						out <- v
+					}
+				}
+			}()
+			return out
+		}
+
+		result := make([]int, 0, len(data))
+		for v := range ExecutePipeline(send(data[:10]), nil, Parallel(nil, odd, 3, OrderInput)) {
+			result = append(result, v.(int))
+		}
+
+		require.Subset(t, []int{1, 3, 5, 7, 9}, result)
+	})
+
+}
+
+func TestParallelLimits(t *testing.T) {
+	t.Run("bounded reorder buffer", func(t *testing.T) {
+		release := make(chan struct{})
+		// the first item waits for release, the rest pass at once
+		stage := func(in In) Out {
+			out := make(Bi)
+			go func() {
+				defer close(out)
+				for v := range in {
+					if v.(int) == 0 {
+						<-release
+					}
+					out <- v
+				}
+			}()
+			return out
+		}
+
+		var taken atomic.Int64
+		in := make(Bi)
+		go func() {
+			defer close(in)
+			for i := 0; i < 50; i++ {
+				in <- i
+				taken.Add(1)
+			}
+		}()
+
+		out := Parallel(nil, stage, 2, OrderInput)(in)
+		time.Sleep(sleepPerStage / 2)
+		// the numbered items and the one waiting for a free slot
+		require.LessOrEqual(t, taken.Load(), int64(2*reorderWindow+1))
+		close(release)
+
+		result := make([]int, 0, 50)
+		for v := range out {
+			result = append(result, v.(int))
+		}
+		require.Len(t, result, 50)
+		require.IsIncreasing(t, result)
+	})
+
+	t.Run("done case", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+		slow := func(in In) Out {
+			out := make(Bi)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer close(out)
+				for v := range in {
+					time.Sleep(sleepPerStage)
+					out <- v
+				}
+			}()
+			return out
+		}
+
+		for _, order := range []Order{OrderInput, OrderCompletion} {
+			done := make(Bi)
+			go func() {
+				<-time.After(sleepPerStage / 2)
+				close(done)
+			}()
+
+			result := make([]interface{}, 0)
+			start := time.Now()
+			for v := range ExecutePipeline(sendCount(50), done, Parallel(done, slow, 4, order), slow) {
+				result = append(result, v)
+			}
+			wg.Wait()
+
+			require.Len(t, result, 0)
+			require.Less(t, time.Since(start), sleepPerStage*2+fault)
+		}
+	})
+}
+
+func TestPipelineContext(t *testing.T) {
+	// Stage generator
+	g := func(f func(v interface{}) interface{}) Stage {
+		return func(in In) Out {
+			out := make(Bi)
+			go func() {
+				defer close(out)
+				for v := range in {
+					time.Sleep(sleepPerStage)
+					out <- f(v)
+				}
+			}()
+			return out
+		}
+	}
+
+	stages := []Stage{
+		g(func(v interface{}) interface{} { return v }),
+		g(func(v interface{}) interface{} { return v.(int) * 2 }),
+		g(func(v interface{}) interface{} { return v.(int) + 100 }),
+		g(func(v interface{}) interface{} { return strconv.Itoa(v.(int)) }),
+	}
+
+	send := func(count int) Bi {
+		in := make(Bi)
+		go func() {
+			defer close(in)
+			for i := 1; i <= count; i++ {
+				in <- i
+			}
+		}()
+		return in
+	}
+
+	collect := func(out Out) []string {
+		result := make([]string, 0, 10)
+		for s := range out {
+			result = append(result, s.(string))
+		}
+		return result
+	}
+
+	t.Run("all items", func(t *testing.T) {
+		out, summary := ExecutePipelineContext(context.Background(), send(5), Options{}, stages...)
+
+		require.Equal(t, []string{"102", "104", "106", "108", "110"}, collect(out))
+		require.Equal(t, Summary{Admitted: 5, Finished: []int{5, 5, 5, 5}}, <-summary)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), sleepPerStage*2)
+		defer cancel()
+
+		start := time.Now()
+		out, summary := ExecutePipelineContext(ctx, send(5), Options{Shutdown: ShutdownAbort}, stages...)
+
+		require.Len(t, collect(out), 0)
+		require.Less(t, time.Since(start), sleepPerStage*2+fault)
+
+		s := <-summary
+		require.True(t, s.Aborted)
+		require.Zero(t, s.Finished[len(stages)-1])
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), sleepPerStage*2+fault)
+		defer cancel()
+
+		out, summary := ExecutePipelineContext(ctx, send(100), Options{Shutdown: ShutdownDrain}, stages...)
+		result := collect(out)
+
+		s := <-summary
+		require.False(t, s.Aborted)
+		require.Less(t, s.Admitted, 100)
+		require.Len(t, result, s.Admitted)
+		require.Equal(t, []int{s.Admitted, s.Admitted, s.Admitted, s.Admitted}, s.Finished)
+		require.Equal(t, []string{"102", "104"}, result[:2])
+	})
+
+	t.Run("drain timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), sleepPerStage*2+fault)
+		defer cancel()
+
+		start := time.Now()
+		out, summary := ExecutePipelineContext(ctx, send(100), Options{
+			Shutdown:     ShutdownDrain,
+			DrainTimeout: sleepPerStage,
+		}, stages...)
+		result := collect(out)
+
+		require.Less(t, time.Since(start), sleepPerStage*4)
+
+		s := <-summary
+		require.True(t, s.Aborted)
+		require.Len(t, result, s.Finished[len(stages)-1])
+		require.Less(t, len(result), s.Admitted)
+	})
+
+	t.Run("nil in", func(t *testing.T) {
+		out, summary := ExecutePipelineContext(context.Background(), nil, Options{}, stages...)
+		require.Len(t, collect(out), 0)
+		require.Equal(t, Summary{Finished: []int{0, 0, 0, 0}}, <-summary)
+	})
+}
+
+type testSink struct {
+	mu  sync.Mutex
+	in  map[string]int
+	out map[string]int
+}
+
+func (s *testSink) ItemIn(stage string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.in[stage]++
+}
+
+func (s *testSink) ItemOut(stage string, _, _ time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.out[stage]++
+}
+
+func TestInstrumentation(t *testing.T) {
+	// Stage generator
+	g := func(d time.Duration, f func(v interface{}) interface{}) Stage {
+		return func(in In) Out {
+			out := make(Bi)
+			go func() {
+				defer close(out)
+				for v := range in {
+					time.Sleep(d)
+					out <- f(v)
+				}
+			}()
+			return out
+		}
+	}
+
+	sink := &testSink{in: make(map[string]int), out: make(map[string]int)}
+	inst := NewInstrumentation(WithSink(sink), WithBuckets(5*time.Millisecond, 50*time.Millisecond))
+
+	stages := []Stage{
+		inst.Stage("fast", g(time.Millisecond, func(v interface{}) interface{} { return v.(int) * 2 }), WithOneToOne()),
+		inst.Stage("", g(20*time.Millisecond, func(v interface{}) interface{} { return v.(int) + 100 }), WithOneToOne()),
+	}
+
+	in := make(Bi)
+	go func() {
+		defer close(in)
+		for i := 1; i <= 5; i++ {
+			in <- i
+		}
+	}()
+
+	result := make([]int, 0, 5)
+	for v := range ExecutePipeline(in, nil, stages...) {
+		result = append(result, v.(int))
+	}
+	require.Equal(t, []int{102, 104, 106, 108, 110}, result)
+
+	snapshot := inst.Snapshot()
+	require.Len(t, snapshot, 2)
+
+	fast, slow := snapshot[0], snapshot[1]
+	require.Equal(t, "fast", fast.Name)
+	require.Equal(t, "stage 1", slow.Name)
+
+	for _, m := range snapshot {
+		require.Equal(t, int64(5), m.In)
+		require.Equal(t, int64(5), m.Out)
+		require.Zero(t, m.InFlight)
+		require.Equal(t, int64(5), m.Processing.Count)
+		require.Len(t, m.Processing.Counts, 3)
+	}
+
+	// the slow stage is the bottleneck, so the fast one waits for it
+	require.Positive(t, fast.Blocked)
+	require.Greater(t, fast.Blocked, slow.Blocked)
+	require.Greater(t, slow.Processing.Sum, 5*20*time.Millisecond)
+	require.Zero(t, slow.Processing.Counts[0])
+
+	require.Equal(t, map[string]int{"fast": 5, "stage 1": 5}, sink.in)
+	require.Equal(t, map[string]int{"fast": 5, "stage 1": 5}, sink.out)
+}
+
+func TestInstrumentationNotOneToOne(t *testing.T) {
+	inst := NewInstrumentation()
+	stages := []Stage{
+		inst.Stage("filter", Filter(nil, func(v interface{}) bool { return v.(int)%2 == 0 })),
+		inst.Stage("batch", Batch(nil, 100, 0)),
+	}
+
+	in := make(Bi)
+	go func() {
+		defer close(in)
+		for i := 1; i <= 5000; i++ {
+			in <- i
+		}
+	}()
+
+	count := 0
+	for range ExecutePipeline(in, nil, stages...) {
+		count++
+	}
+	require.Equal(t, 25, count)
+
+	snapshot := inst.Snapshot()
+	filter, batch := snapshot[0], snapshot[1]
+	require.Equal(t, int64(5000), filter.In)
+	require.Equal(t, int64(2500), filter.Out)
+	require.Equal(t, int64(2500), batch.In)
+	require.Equal(t, int64(25), batch.Out)
+
+	for _, m := range snapshot {
+		require.Zero(t, m.InFlight)
+		require.Zero(t, m.Processing.Count)
+	}
+}
+
+// sendAll sends data and closes the returned channel.
+func sendAll(data ...interface{}) Bi {
+	in := make(Bi)
+	go func() {
+		defer close(in)
+		for _, v := range data {
+			in <- v
+		}
+	}()
+	return in
+}
+
+// sendEvery sends 1..count with the given interval.
+func sendEvery(count int, interval time.Duration) Bi {
+	in := make(Bi)
+	go func() {
+		defer close(in)
+		for i := 1; i <= count; i++ {
+			in <- i
+			time.Sleep(interval)
+		}
+	}()
+	return in
+}
+
+// collect reads out until it is closed.
+func collect(out Out) []interface{} {
+	result := make([]interface{}, 0)
+	for v := range out {
+		result = append(result, v)
+	}
+	return result
+}
+
+func TestMapFilterTee(t *testing.T) {
+	t.Run("map and filter", func(t *testing.T) {
+		out := ExecutePipeline(sendAll(1, 2, 3, 4, 5, 6), nil,
+			Filter(nil, func(v interface{}) bool { return v.(int)%2 == 0 }),
+			Map(nil, func(v interface{}) interface{} { return strconv.Itoa(v.(int)) }),
+		)
+		require.Equal(t, []interface{}{"2", "4", "6"}, collect(out))
+	})
+
+	t.Run("tee", func(t *testing.T) {
+		side := make(Bi)
+		var teed []interface{}
+		teeDone := make(chan struct{})
+		go func() {
+			defer close(teeDone)
+			teed = collect(side)
+		}()
+
+		out := ExecutePipeline(sendAll(1, 2, 3), nil,
+			Tee(nil, side),
+			Map(nil, func(v interface{}) interface{} { return v.(int) * 10 }),
+		)
+		require.Equal(t, []interface{}{10, 20, 30}, collect(out))
+
+		<-teeDone
+		require.Equal(t, []interface{}{1, 2, 3}, teed)
+	})
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("batch and unbatch", func(t *testing.T) {
+		out := ExecutePipeline(sendAll(1, 2, 3, 4, 5), nil, Batch(nil, 2, 0))
+		require.Equal(t, []interface{}{
+			[]interface{}{1, 2},
+			[]interface{}{3, 4},
+			[]interface{}{5},
+		}, collect(out))
+
+		out = ExecutePipeline(sendAll(1, 2, 3, 4, 5), nil, Batch(nil, 2, 0), Unbatch(nil))
+		require.Equal(t, []interface{}{1, 2, 3, 4, 5}, collect(out))
+
+		out = ExecutePipeline(sendAll([]interface{}{1, 2}, 3, "4"), nil, Unbatch(nil))
+		require.Equal(t, []interface{}{1, 2, 3, "4"}, collect(out))
+	})
+
+	t.Run("batch max wait", func(t *testing.T) {
+		in := make(Bi)
+		go func() {
+			defer close(in)
+			in <- 1
+			time.Sleep(sleepPerStage)
+			in <- 2
+			in <- 3
+			in <- 4
+		}()
+
+		start := time.Now()
+		out := ExecutePipeline(in, nil, Batch(nil, 3, sleepPerStage/4))
+
+		first := <-out
+		require.Equal(t, []interface{}{1}, first)
+		require.Less(t, time.Since(start), sleepPerStage/2)
+		require.Equal(t, []interface{}{[]interface{}{2, 3, 4}}, collect(out))
+	})
+}
+
+func TestWindows(t *testing.T) {
+	t.Run("tumbling window", func(t *testing.T) {
+		out := ExecutePipeline(sendEvery(10, 10*time.Millisecond), nil, TumblingWindow(nil, 35*time.Millisecond))
+
+		windows := collect(out)
+		require.GreaterOrEqual(t, len(windows), 2)
+
+		var items []interface{}
+		for _, w := range windows {
+			require.NotEmpty(t, w)
+			items = append(items, w.([]interface{})...)
+		}
+		require.Equal(t, []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, items)
+	})
+
+	t.Run("sliding window", func(t *testing.T) {
+		out := ExecutePipeline(sendEvery(10, 10*time.Millisecond), nil,
+			SlidingWindow(nil, 40*time.Millisecond, 20*time.Millisecond))
+
+		windows := collect(out)
+		require.GreaterOrEqual(t, len(windows), 3)
+
+		seen := make(map[interface{}]int)
+		for _, w := range windows {
+			for _, v := range w.([]interface{}) {
+				seen[v]++
+			}
+		}
+		require.Len(t, seen, 10)
+
+		overlapped := 0
+		for _, count := range seen {
+			if count > 1 {
+				overlapped++
+			}
+		}
+		require.Positive(t, overlapped, "windows don't overlap")
+	})
+
+	t.Run("sliding window with gaps", func(t *testing.T) {
+		const size, step = 10 * time.Millisecond, 50 * time.Millisecond
+
+		in := make(Bi)
+		go func() {
+			defer close(in)
+			for i := 0; i < 60; i++ {
+				in <- time.Now()
+				time.Sleep(5 * time.Millisecond)
+			}
+		}()
+
+		windows := collect(ExecutePipeline(in, nil, SlidingWindow(nil, size, step)))
+		require.GreaterOrEqual(t, len(windows), 3)
+
+		count := 0
+		for _, w := range windows {
+			items := w.([]interface{})
+			count += len(items)
+			first, last := items[0].(time.Time), items[len(items)-1].(time.Time)
+			require.LessOrEqual(t, last.Sub(first), size+step/4, "items from outside of the window")
+		}
+		require.Less(t, count, 30, "items from the gaps are sent")
+	})
+
+	t.Run("non-positive window periods", func(t *testing.T) {
+		for _, stage := range []Stage{
+			TumblingWindow(nil, 0),
+			SlidingWindow(nil, -time.Second, 0),
+			SlidingWindow(nil, 0, -time.Second),
+		} {
+			var items []interface{}
+			for w := range ExecutePipeline(sendAll(1, 2, 3), nil, stage) {
+				items = append(items, w.([]interface{})...)
+			}
+			require.Equal(t, []interface{}{1, 2, 3}, items)
+		}
+	})
+}
+
+func TestCombinatorsDone(t *testing.T) {
+	t.Run("done case", func(t *testing.T) {
+		done := make(Bi)
+		stages := []Stage{
+			Map(done, func(v interface{}) interface{} { return v }),
+			Filter(done, func(interface{}) bool { return true }),
+			Tee(done, make(Bi)),
+			Batch(done, 100, 0),
+			Unbatch(done),
+			TumblingWindow(done, time.Second),
+			SlidingWindow(done, time.Second, time.Second),
+		}
+
+		go func() {
+			<-time.After(sleepPerStage)
+			close(done)
+		}()
+
+		start := time.Now()
+		result := collect(ExecutePipeline(sendEvery(100, time.Millisecond), done, stages...))
+		require.Len(t, result, 0)
+		require.Less(t, time.Since(start), sleepPerStage+fault)
+	})
+}
+
+// delayStage returns a stage passing items on after the delay returned by d for every item.
+func delayStage(d func(v int) time.Duration) Stage {
+	return func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			for v := range in {
+				time.Sleep(d(v.(int)))
+				out <- v
+			}
+		}()
+		return out
+	}
+}
+
+// sendCount sends 0..count-1 and closes the returned channel.
+func sendCount(count int) Bi {
+	in := make(Bi)
+	go func() {
+		defer close(in)
+		for i := 0; i < count; i++ {
+			in <- i
+		}
+	}()
+	return in
+}
+
+func TestPipelineLimits(t *testing.T) {
+	t.Run("buffers smooth varying stages", func(t *testing.T) {
+		// the first stage is slow on the second half of items and the second one on the first half,
+		// so without buffers the first stage can't go ahead while the second one is busy
+		stages := []Stage{
+			delayStage(func(v int) time.Duration { return time.Duration(v/5) * 20 * time.Millisecond }),
+			delayStage(func(v int) time.Duration { return time.Duration(1-v/5) * 20 * time.Millisecond }),
+		}
+
+		run := func(opts Options) time.Duration {
+			start := time.Now()
+			out, summary := ExecutePipelineContext(context.Background(), sendCount(10), opts, stages...)
+			for range out {
+				continue
+			}
+			require.Equal(t, []int{10, 10}, (<-summary).Finished)
+			return time.Since(start)
+		}
+
+		unbuffered := run(Options{})
+		buffered := run(Options{Buffers: []int{0, 10, 10}})
+		require.Less(t, buffered, unbuffered)
+	})
+
+	t.Run("options without context", func(t *testing.T) {
+		stages := []Stage{
+			Filter(nil, func(v interface{}) bool { return v.(int)%2 == 0 }),
+			delayStage(func(int) time.Duration { return time.Millisecond }),
+		}
+
+		opts := Options{Buffers: []int{2, 2}, MaxInFlight: 2}
+		result := make([]interface{}, 0, 10)
+		for v := range ExecutePipelineWithOptions(sendCount(20), nil, opts, stages...) {
+			result = append(result, v)
+		}
+		require.Equal(t, []interface{}{0, 2, 4, 6, 8, 10, 12, 14, 16, 18}, result)
+
+		done := make(Bi)
+		close(done)
+		for range ExecutePipelineWithOptions(sendCount(20), done, Options{MaxInFlight: 2}, stages...) {
+			continue
+		}
+	})
+}
+
+func TestMaxInFlight(t *testing.T) {
+	t.Run("max in flight", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			inFlight int
+			maxSeen  int
+		)
+		track := func(delta int) {
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight += delta
+			maxSeen = max(maxSeen, inFlight)
+		}
+
+		in := make(Bi)
+		go func() {
+			defer close(in)
+			for i := 0; i < 50; i++ {
+				track(1)
+				in <- i
+			}
+		}()
+
+		stages := []Stage{
+			delayStage(func(int) time.Duration { return 0 }),
+			delayStage(func(int) time.Duration { return time.Millisecond }),
+		}
+
+		out, summary := ExecutePipelineContext(context.Background(), in, Options{
+			Buffers:     []int{10, 10},
+			MaxInFlight: 5,
+		}, stages...)
+
+		count := 0
+		for range out {
+			track(-1)
+			count++
+		}
+
+		require.Equal(t, 50, count)
+		require.Equal(t, 50, (<-summary).Admitted)
+		// items inside the stages and items leaving them don't wait for the limit,
+		// the item waiting to be taken from the producer and the item just received are tracked too
+		require.LessOrEqual(t, maxSeen, 5+2*len(stages)+2)
+	})
+
+	t.Run("max in flight with stages changing item count", func(t *testing.T) {
+		for _, tc := range []struct {
+			name   string
+			stages []Stage
+			count  int
+		}{
+			{
+				name:   "filter",
+				stages: []Stage{Filter(nil, func(v interface{}) bool { return v.(int)%2 == 0 })},
+				count:  10,
+			},
+			{
+				name:   "batch",
+				stages: []Stage{Batch(nil, 3, 0)},
+				count:  7,
+			},
+			{
+				name: "unbatch",
+				stages: []Stage{
+					Map(nil, func(v interface{}) interface{} { return []interface{}{v, v, v} }),
+					Unbatch(nil),
+				},
+				count: 60,
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				out, summary := ExecutePipelineContext(context.Background(), sendCount(20), Options{
+					Buffers:     []int{1, 1, 1},
+					MaxInFlight: 2,
+				}, tc.stages...)
+
+				count := 0
+				for range out {
+					count++
+				}
+				require.Equal(t, tc.count, count)
+				require.Equal(t, 20, (<-summary).Admitted)
+			})
+		}
+	})
+
+	t.Run("max in flight without stages", func(t *testing.T) {
+		out, summary := ExecutePipelineContext(context.Background(), sendCount(10), Options{MaxInFlight: 1})
+		for range out {
+			continue
+		}
+		require.Equal(t, 10, (<-summary).Admitted)
+	})
+
+	t.Run("cancel while waiting for in flight limit", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		out, summary := ExecutePipelineContext(ctx, sendCount(10), Options{MaxInFlight: 2})
+
+		<-out
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		for range out {
+			continue
+		}
+		require.LessOrEqual(t, (<-summary).Admitted, 3)
+	})
+}
@@ -1,15 +1,264 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 )
 
+// overlapSize is the size of the tail of a partial copy which is compared with the source before resuming.
+const overlapSize = 64 << 10
+
 var (
 	ErrUnsupportedFile       = errors.New("unsupported file")
 	ErrOffsetExceedsFileSize = errors.New("offset exceeds file size")
+	ErrNegativeOffsetOrLimit = errors.New("offset and limit must not be negative")
+	ErrChecksumMismatch      = errors.New("checksum mismatch")
+	ErrResumeMismatch        = errors.New("partial copy doesn't match the source")
+	ErrCopyInProgress        = errors.New("another copy to the same file is in progress")
 )
 
+type Options struct {
+	// Progress receives the progress bar, nil means no progress is shown.
+	Progress io.Writer
+	// Verify compares checksums of the copied part of the source and of the written file.
+	Verify bool
+	// Resume continues an interrupted copy from the end of its partial file.
+	// The partial file is kept on errors other than ErrChecksumMismatch, so the copy can be resumed again.
+	Resume bool
+	// Sparse skips holes of the source, they stay holes in the copy.
+	Sparse bool
+}
+
 func Copy(fromPath, toPath string, offset, limit int64) error {
-	// Place your code here.
+	return CopyWithOptions(fromPath, toPath, offset, limit, Options{})
+}
+
+// CopyWithOptions copies limit bytes of fromPath starting at offset to toPath, zero limit means up to the end.
+// The data is written to a partial file next to toPath which replaces toPath only after a successful copy,
+// so an interrupted copy can be resumed with Resume.
+func CopyWithOptions(fromPath, toPath string, offset, limit int64, opts Options) error {
+	if offset < 0 || limit < 0 {
+		return ErrNegativeOffsetOrLimit
+	}
+
+	src, err := os.Open(fromPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s", ErrUnsupportedFile, fromPath)
+	}
+
+	if offset > info.Size() {
+		return ErrOffsetExceedsFileSize
+	}
+
+	size := info.Size() - offset
+	if limit > 0 && limit < size {
+		size = limit
+	}
+
+	section := io.NewSectionReader(src, offset, size)
+
+	partPath := partialPath(toPath)
+	part, copied, err := openPartial(partPath, section, opts.Resume)
+	if err != nil {
+		return err
+	}
+
+	// the partial file is renamed and removed while it is locked,
+	// so a concurrent copy can't take it over in between
+	err = writePartial(part, src, section, copied, info.Mode().Perm(), opts)
+	if err == nil {
+		err = os.Rename(partPath, toPath)
+	}
+
+	// a corrupt partial copy would fail every resumed copy again
+	if err != nil && (!opts.Resume || errors.Is(err, ErrChecksumMismatch)) {
+		os.Remove(partPath)
+	}
+
+	if closeErr := part.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// partialPath returns the path of the file which holds the copy until it is complete.
+func partialPath(toPath string) string {
+	return filepath.Join(filepath.Dir(toPath), "."+filepath.Base(toPath)+".part")
+}
+
+// openPartial opens and locks the partial file and returns the number of bytes which are already copied to it.
+// Without resume the file is truncated. A partial file locked by another copy gives ErrCopyInProgress.
+func openPartial(path string, src *io.SectionReader, resume bool) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+
+	// the copy which held the lock may have renamed or removed the file
+	if current, err := os.Stat(path); err != nil || !os.SameFile(info, current) {
+		f.Close()
+		return nil, 0, fmt.Errorf("%s: %w", path, ErrCopyInProgress)
+	}
+
+	if !resume {
+		if err := f.Truncate(0); err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		return f, 0, nil
+	}
+
+	if err := checkOverlap(f, src, info.Size()); err != nil {
+		f.Close()
+		// the partial file is kept, it has to be removed to start over
+		return nil, 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return f, info.Size(), nil
+}
+
+// checkOverlap compares the tail of the first copied bytes of part with the same bytes of src.
+func checkOverlap(part io.ReaderAt, src *io.SectionReader, copied int64) error {
+	if copied > src.Size() {
+		return fmt.Errorf("%w: partial copy is larger than the source", ErrResumeMismatch)
+	}
+
+	n := min(copied, overlapSize)
+	if n == 0 {
+		return nil
+	}
+
+	expected := make([]byte, n)
+	actual := make([]byte, n)
+
+	if _, err := src.ReadAt(expected, copied-n); err != nil {
+		return err
+	}
+
+	if _, err := part.ReadAt(actual, copied-n); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(expected, actual) {
+		return ErrResumeMismatch
+	}
+
 	return nil
 }
+
+// writePartial copies the bytes of section after the first copied ones to part.
+func writePartial(
+	part, src *os.File, section *io.SectionReader, copied int64, perm os.FileMode, opts Options,
+) (err error) {
+	var bar *progressBar
+	if opts.Progress != nil {
+		bar = newProgressBar(opts.Progress, section.Size())
+		defer bar.Finish()
+		bar.Add(copied)
+	}
+
+	extents := []extent{{offset: copied, length: section.Size() - copied}}
+	if opts.Sparse {
+		_, base, _ := section.Outer()
+		if extents, err = dataExtents(src, base+copied, section.Size()-copied); err != nil {
+			return err
+		}
+		for i := range extents {
+			extents[i].offset -= base
+		}
+	}
+
+	pos := copied
+	for _, e := range extents {
+		if _, err := part.Seek(e.offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		var w io.Writer = part
+		if bar != nil {
+			bar.Add(e.offset - pos)
+			w = io.MultiWriter(part, bar)
+		}
+
+		if _, err := io.CopyN(w, io.NewSectionReader(section, e.offset, e.length), e.length); err != nil {
+			return err
+		}
+		pos = e.offset + e.length
+	}
+
+	// the copy may end with a hole
+	if err := part.Truncate(section.Size()); err != nil {
+		return err
+	}
+	if bar != nil {
+		bar.Add(section.Size() - pos)
+	}
+
+	if err := part.Chmod(perm); err != nil {
+		return err
+	}
+
+	if err := part.Sync(); err != nil {
+		return err
+	}
+
+	if opts.Verify {
+		return verifyChecksum(part, section)
+	}
+
+	return nil
+}
+
+// verifyChecksum compares the checksums of the contents of f and src.
+func verifyChecksum(f io.ReaderAt, src *io.SectionReader) error {
+	expected, err := checksum(io.NewSectionReader(src, 0, src.Size()))
+	if err != nil {
+		return err
+	}
+
+	actual, err := checksum(io.NewSectionReader(f, 0, src.Size()+1))
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(expected, actual) {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+func checksum(r io.Reader) ([]byte, error) {
+	sum := sha256.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return nil, err
+	}
+	return sum.Sum(nil), nil
+}
@@ -1,7 +1,307 @@
 package main
 
-import "testing"
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
 
 func TestCopy(t *testing.T) {
-	// Place your code here.
+	tests := []struct {
+		offset, limit int64
+		expected      string
+	}{
+		{offset: 0, limit: 0, expected: "testdata/out_offset0_limit0.txt"},
+		{offset: 0, limit: 10, expected: "testdata/out_offset0_limit10.txt"},
+		{offset: 0, limit: 1000, expected: "testdata/out_offset0_limit1000.txt"},
+		{offset: 0, limit: 10000, expected: "testdata/out_offset0_limit10000.txt"},
+		{offset: 100, limit: 1000, expected: "testdata/out_offset100_limit1000.txt"},
+		{offset: 6000, limit: 1000, expected: "testdata/out_offset6000_limit1000.txt"},
+	}
+
+	for _, tc := range tests {
+		t.Run(filepath.Base(tc.expected), func(t *testing.T) {
+			to := filepath.Join(t.TempDir(), "out.txt")
+
+			require.NoError(t, Copy("testdata/input.txt", to, tc.offset, tc.limit))
+
+			expected, err := os.ReadFile(tc.expected)
+			require.NoError(t, err)
+			actual, err := os.ReadFile(to)
+			require.NoError(t, err)
+			require.Equal(t, expected, actual)
+		})
+	}
+
+	t.Run("offset equals file size", func(t *testing.T) {
+		info, err := os.Stat("testdata/input.txt")
+		require.NoError(t, err)
+
+		to := filepath.Join(t.TempDir(), "out.txt")
+		require.NoError(t, Copy("testdata/input.txt", to, info.Size(), 0))
+
+		actual, err := os.ReadFile(to)
+		require.NoError(t, err)
+		require.Empty(t, actual)
+	})
+}
+
+func TestCopyErrors(t *testing.T) {
+	t.Run("offset exceeds file size", func(t *testing.T) {
+		err := Copy("testdata/input.txt", filepath.Join(t.TempDir(), "out.txt"), 1<<20, 0)
+		require.ErrorIs(t, err, ErrOffsetExceedsFileSize)
+	})
+
+	t.Run("unsupported files", func(t *testing.T) {
+		for _, from := range []string{"/dev/urandom", t.TempDir()} {
+			err := Copy(from, filepath.Join(t.TempDir(), "out.txt"), 0, 0)
+			require.ErrorIs(t, err, ErrUnsupportedFile)
+		}
+	})
+
+	t.Run("negative arguments", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "out.txt")
+		require.ErrorIs(t, Copy("testdata/input.txt", to, -1, 0), ErrNegativeOffsetOrLimit)
+		require.ErrorIs(t, Copy("testdata/input.txt", to, 0, -1), ErrNegativeOffsetOrLimit)
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		err := Copy("testdata/missing.txt", filepath.Join(t.TempDir(), "out.txt"), 0, 0)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("destination is kept on error", func(t *testing.T) {
+		dir := t.TempDir()
+		to := filepath.Join(dir, "out.txt")
+		require.NoError(t, os.WriteFile(to, []byte("old"), 0o600))
+
+		require.Error(t, Copy("testdata/input.txt", to, 1<<20, 0))
+
+		actual, err := os.ReadFile(to)
+		require.NoError(t, err)
+		require.Equal(t, "old", string(actual))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1, "temporary file is left")
+	})
+}
+
+func TestCopyWithOptions(t *testing.T) {
+	t.Run("progress", func(t *testing.T) {
+		progress := &bytes.Buffer{}
+		to := filepath.Join(t.TempDir(), "out.txt")
+
+		require.NoError(t, CopyWithOptions("testdata/input.txt", to, 0, 0, Options{Progress: progress}))
+		require.Contains(t, progress.String(), "  0%")
+		require.Contains(t, progress.String(), "100%\n")
+	})
+
+	t.Run("progress bar", func(t *testing.T) {
+		progress := &bytes.Buffer{}
+		bar := newProgressBar(progress, 200)
+		_, err := bar.Write(make([]byte, 50))
+		require.NoError(t, err)
+		_, err = bar.Write(make([]byte, 1))
+		require.NoError(t, err)
+
+		require.Equal(t, "\r["+string(bytes.Repeat([]byte(" "), 50))+"]   0%"+
+			"\r["+string(bytes.Repeat([]byte("#"), 12))+string(bytes.Repeat([]byte(" "), 38))+"]  25%",
+			progress.String())
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "out.txt")
+		require.NoError(t, CopyWithOptions("testdata/input.txt", to, 100, 1000, Options{Verify: true}))
+
+		expected, err := os.ReadFile("testdata/out_offset100_limit1000.txt")
+		require.NoError(t, err)
+		actual, err := os.ReadFile(to)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		f, err := os.CreateTemp(t.TempDir(), "out")
+		require.NoError(t, err)
+		_, err = f.WriteString("copied data")
+		require.NoError(t, err)
+
+		require.NoError(t, verifyChecksum(f, io.NewSectionReader(strings.NewReader("copied data"), 0, 11)))
+		require.ErrorIs(t, verifyChecksum(f, io.NewSectionReader(strings.NewReader("source data"), 0, 11)),
+			ErrChecksumMismatch)
+		require.ErrorIs(t, verifyChecksum(f, io.NewSectionReader(strings.NewReader("copied"), 0, 6)),
+			ErrChecksumMismatch)
+		require.NoError(t, f.Close())
+	})
+}
+
+func TestCopyResume(t *testing.T) {
+	expected, err := os.ReadFile("testdata/out_offset100_limit1000.txt")
+	require.NoError(t, err)
+
+	t.Run("continue partial copy", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "out.txt")
+		require.NoError(t, os.WriteFile(partialPath(to), expected[:300], 0o600))
+
+		progress := &bytes.Buffer{}
+		err := CopyWithOptions("testdata/input.txt", to, 100, 1000, Options{Resume: true, Progress: progress})
+		require.NoError(t, err)
+		require.Contains(t, progress.String(), " 30%")
+
+		actual, err := os.ReadFile(to)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+		require.NoFileExists(t, partialPath(to))
+	})
+
+	t.Run("no partial copy", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "out.txt")
+		require.NoError(t, CopyWithOptions("testdata/input.txt", to, 100, 1000, Options{Resume: true}))
+
+		actual, err := os.ReadFile(to)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("overlap mismatch", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "out.txt")
+		partial := append(bytes.Clone(expected[:299]), expected[299]+1)
+		require.NoError(t, os.WriteFile(partialPath(to), partial, 0o600))
+
+		err := CopyWithOptions("testdata/input.txt", to, 100, 1000, Options{Resume: true})
+		require.ErrorIs(t, err, ErrResumeMismatch)
+		require.Contains(t, err.Error(), partialPath(to))
+		require.NoFileExists(t, to)
+
+		// the partial copy is kept for the next attempt
+		actual, err := os.ReadFile(partialPath(to))
+		require.NoError(t, err)
+		require.Equal(t, partial, actual)
+	})
+
+	t.Run("partial copy is larger than source", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "out.txt")
+		require.NoError(t, os.WriteFile(partialPath(to), append(bytes.Clone(expected), 'x'), 0o600))
+
+		err := CopyWithOptions("testdata/input.txt", to, 100, 1000, Options{Resume: true})
+		require.ErrorIs(t, err, ErrResumeMismatch)
+	})
+
+	t.Run("empty section", func(t *testing.T) {
+		info, err := os.Stat("testdata/input.txt")
+		require.NoError(t, err)
+
+		to := filepath.Join(t.TempDir(), "out.txt")
+		require.NoError(t, CopyWithOptions("testdata/input.txt", to, info.Size(), 0, Options{Resume: true}))
+
+		actual, err := os.ReadFile(to)
+		require.NoError(t, err)
+		require.Empty(t, actual)
+		require.NoFileExists(t, partialPath(to))
+	})
+
+	t.Run("corrupt partial copy is removed", func(t *testing.T) {
+		dir := t.TempDir()
+		from := filepath.Join(dir, "in")
+		data := bytes.Repeat([]byte("0123456789"), 2*overlapSize/10)
+		require.NoError(t, os.WriteFile(from, data, 0o600))
+
+		// the corrupt byte is out of the overlap which is compared before resuming
+		to := filepath.Join(dir, "out")
+		partial := bytes.Clone(data)
+		partial[1000]++
+		require.NoError(t, os.WriteFile(partialPath(to), partial, 0o600))
+
+		err := CopyWithOptions(from, to, 0, 0, Options{Resume: true, Verify: true})
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+		require.NoFileExists(t, partialPath(to))
+
+		require.NoError(t, CopyWithOptions(from, to, 0, 0, Options{Resume: true, Verify: true}))
+		actual, err := os.ReadFile(to)
+		require.NoError(t, err)
+		require.Equal(t, data, actual)
+	})
+
+	t.Run("copy without resume starts over", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "out.txt")
+		require.NoError(t, os.WriteFile(partialPath(to), []byte("garbage"), 0o600))
+
+		require.NoError(t, Copy("testdata/input.txt", to, 100, 1000))
+
+		actual, err := os.ReadFile(to)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+		require.NoFileExists(t, partialPath(to))
+	})
+
+	t.Run("copy in progress", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "out.txt")
+		part, err := os.OpenFile(partialPath(to), os.O_RDWR|os.O_CREATE, 0o600)
+		require.NoError(t, err)
+		defer part.Close()
+		requireLocked(t, part)
+
+		for _, resume := range []bool{false, true} {
+			err := CopyWithOptions("testdata/input.txt", to, 100, 1000, Options{Resume: resume})
+			require.ErrorIs(t, err, ErrCopyInProgress)
+			require.Contains(t, err.Error(), partialPath(to))
+		}
+		require.FileExists(t, partialPath(to))
+	})
+}
+
+func TestCopySparse(t *testing.T) {
+	const size = 8 << 20
+
+	dir := t.TempDir()
+	from := filepath.Join(dir, "sparse")
+
+	f, err := os.Create(from)
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(size))
+	_, err = f.WriteAt([]byte("head"), 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("middle"), size/2)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	expected, err := os.ReadFile(from)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name          string
+		offset, limit int64
+	}{
+		{name: "whole file"},
+		{name: "from hole", offset: 1 << 20},
+		{name: "ends in hole", offset: 1, limit: size/2 + 1<<20},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			to := filepath.Join(dir, "out")
+			progress := &bytes.Buffer{}
+			require.NoError(t, CopyWithOptions(from, to, tc.offset, tc.limit, Options{
+				Sparse:   true,
+				Verify:   true,
+				Progress: progress,
+			}))
+			require.Contains(t, progress.String(), "100%")
+
+			actual, err := os.ReadFile(to)
+			require.NoError(t, err)
+
+			end := int64(len(expected))
+			if tc.limit > 0 {
+				end = tc.offset + tc.limit
+			}
+			require.True(t, bytes.Equal(expected[tc.offset:end], actual))
+
+			requireSparse(t, to)
+		})
+	}
 }
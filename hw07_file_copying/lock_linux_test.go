@@ -0,0 +1,14 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requireLocked locks f like a running copy does.
+func requireLocked(t *testing.T, f *os.File) {
+	t.Helper()
+	require.NoError(t, lockFile(f))
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// lockFile does nothing, concurrent copies to the same path are not detected on this platform.
+func lockFile(_ *os.File) error {
+	return nil
+}
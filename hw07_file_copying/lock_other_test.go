@@ -0,0 +1,14 @@
+//go:build !linux
+
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// requireLocked skips the test, files are not locked on this platform.
+func requireLocked(t *testing.T, _ *os.File) {
+	t.Helper()
+	t.Skip("copies are not locked on this platform")
+}
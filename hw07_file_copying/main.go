@@ -2,11 +2,16 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
 )
 
 var (
 	from, to      string
 	limit, offset int64
+	verify        bool
+	resume        bool
+	sparse        bool
 )
 
 func init() {
@@ -14,9 +19,27 @@ func init() {
 	flag.StringVar(&to, "to", "", "file to write to")
 	flag.Int64Var(&limit, "limit", 0, "limit of bytes to copy")
 	flag.Int64Var(&offset, "offset", 0, "offset in input file")
+	flag.BoolVar(&verify, "verify", false, "compare checksums of the source and the copy")
+	flag.BoolVar(&resume, "resume", false, "continue an interrupted copy")
+	flag.BoolVar(&sparse, "sparse", false, "keep holes of the source in the copy")
 }
 
 func main() {
 	flag.Parse()
-	// Place your code here.
+
+	if from == "" || to == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := CopyWithOptions(from, to, offset, limit, Options{
+		Progress: os.Stderr,
+		Verify:   verify,
+		Resume:   resume,
+		Sparse:   sparse,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
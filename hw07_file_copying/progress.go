@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const progressBarWidth = 50
+
+// progressBar redraws a line like "[#####     ] 50%" on every percent of written bytes.
+type progressBar struct {
+	w       io.Writer
+	total   int64
+	written int64
+	percent int
+}
+
+func newProgressBar(w io.Writer, total int64) *progressBar {
+	bar := &progressBar{w: w, total: total, percent: -1}
+	bar.draw()
+	return bar
+}
+
+func (b *progressBar) Write(p []byte) (int, error) {
+	b.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Add counts n bytes which are done without writing, like skipped holes.
+func (b *progressBar) Add(n int64) {
+	b.written += n
+	b.draw()
+}
+
+// Finish ends the line of the bar.
+func (b *progressBar) Finish() {
+	fmt.Fprintln(b.w)
+}
+
+func (b *progressBar) draw() {
+	percent := 100
+	if b.total > 0 {
+		percent = int(b.written * 100 / b.total)
+	}
+
+	if percent == b.percent {
+		return
+	}
+	b.percent = percent
+
+	done := progressBarWidth * percent / 100
+	fmt.Fprintf(b.w, "\r[%s%s] %3d%%", strings.Repeat("#", done), strings.Repeat(" ", progressBarWidth-done), percent)
+}
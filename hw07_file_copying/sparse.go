@@ -0,0 +1,7 @@
+package main
+
+// extent is a range of bytes of a file.
+type extent struct {
+	offset int64
+	length int64
+}
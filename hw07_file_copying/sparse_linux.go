@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// whence values of lseek which are missing in syscall.
+const (
+	seekData = 3
+	seekHole = 4
+)
+
+// dataExtents returns ranges of data of f between start and start+length, holes are skipped.
+// The whole range is returned if the file system doesn't support looking for holes.
+func dataExtents(f *os.File, start, length int64) ([]extent, error) {
+	end := start + length
+
+	var extents []extent
+	for pos := start; pos < end; {
+		data, err := f.Seek(pos, seekData)
+		if errors.Is(err, syscall.ENXIO) {
+			// no data after pos
+			break
+		}
+		if errors.Is(err, syscall.EINVAL) {
+			return []extent{{offset: start, length: length}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if data >= end {
+			break
+		}
+
+		hole, err := f.Seek(data, seekHole)
+		if err != nil {
+			return nil, err
+		}
+
+		extents = append(extents, extent{offset: data, length: min(hole, end) - data})
+		pos = hole
+	}
+
+	return extents, nil
+}
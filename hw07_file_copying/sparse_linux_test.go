@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requireSparse checks that the file takes less disk space than its size.
+func requireSparse(t *testing.T, path string) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	require.True(t, ok)
+	// Blocks are 512 bytes
+	require.Less(t, stat.Blocks*512, info.Size()/2, "holes are filled")
+}
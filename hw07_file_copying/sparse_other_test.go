@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+import "testing"
+
+func requireSparse(t *testing.T, _ string) {
+	t.Helper()
+}